@@ -0,0 +1,174 @@
+package tiktak
+
+import (
+	"time"
+)
+
+// Buckets split time into consecutive intervals, e.g. days or weeks.
+type Buckets interface {
+	// Bucket returns the interval start–end that contains t. A zero end
+	// means the bucket has no end.
+	Bucket(t time.Time) (start, end time.Time)
+}
+
+type DayBuckets struct{ Location *time.Location }
+
+func (b DayBuckets) Bucket(t time.Time) (start, end time.Time) {
+	return StartDay(t, 0, b.Location), StartDay(t, 1, b.Location)
+}
+
+type WeekBuckets struct {
+	Start time.Weekday
+	// MinDays selects the week numbers of labels, see [WeekNumbering]. Zero
+	// means 4 like ISO weeks.
+	MinDays  int
+	Location *time.Location
+}
+
+func (b WeekBuckets) Bucket(t time.Time) (start, end time.Time) {
+	day := StartDay(t, 0, b.Location)
+	return LastDay(b.Start, day, b.Location), NextDay(b.Start, day, b.Location)
+}
+
+type MonthBuckets struct{ Location *time.Location }
+
+func (b MonthBuckets) Bucket(t time.Time) (start, end time.Time) {
+	return StartMonth(t, 0, b.Location), StartMonth(t, 1, b.Location)
+}
+
+// TotalBucket puts all time into a single bucket.
+type TotalBucket struct{}
+
+func (TotalBucket) Bucket(time.Time) (start, end time.Time) { return }
+
+// Cell is the aggregated time of a task in a single bucket.
+type Cell struct {
+	Duration time.Duration
+	// Start is the earliest and End the latest time of all spans in the
+	// cell, both clipped to the bucket.
+	Start, End time.Time
+	// Open is true if a running span contributes to the cell.
+	Open bool
+	// Warning is true if a switch that starts in the bucket has a warning.
+	Warning bool
+}
+
+func (c *Cell) add(s Span, start, end time.Time) {
+	if c.Start.IsZero() || start.Before(c.Start) {
+		c.Start = start
+	}
+	if c.End.IsZero() || end.After(c.End) {
+		c.End = end
+	}
+	c.Duration += end.Sub(start)
+	c.Open = c.Open || s.Open
+}
+
+type cellKey struct {
+	task   *Task
+	sub    bool
+	dim    int
+	bucket int64
+}
+
+// Cube holds the durations of tasks aggregated per bucket for each of its
+// dimensions. Cells exist for a task's own time and for the time of the
+// task's subtree, i.e. the task including all its subtasks.
+type Cube struct {
+	dims  []Buckets
+	cells map[cellKey]*Cell
+}
+
+// Aggregate walks the spans of tl in the range from–to once and sums them up
+// into a Cube with one dimension for each element of dims.
+func Aggregate(tl TimeLine, from, to, now time.Time, dims ...Buckets) *Cube {
+	c := &Cube{
+		dims:  dims,
+		cells: make(map[cellKey]*Cell),
+	}
+	var warns []int
+	for s := range tl.Spans(from, to, now) {
+		warns = s.Switch.SelectNotes(warns[:0], Warning)
+		warn := len(warns) > 0 && s.Start.Equal(s.Switch.When())
+		for dim, bs := range dims {
+			if warn {
+				bstart, _ := bs.Bucket(s.Start)
+				c.forTask(s.Task, dim, bstart, func(cl *Cell) { cl.Warning = true })
+			}
+			if s.Open {
+				bstart, _ := bs.Bucket(s.Start)
+				c.forTask(s.Task, dim, bstart, func(cl *Cell) { cl.Open = true })
+			}
+			if s.End.IsZero() {
+				continue
+			}
+			if s.End.Before(s.Start) {
+				bstart, _ := bs.Bucket(s.Start)
+				c.forTask(s.Task, dim, bstart, func(cl *Cell) { cl.add(s, s.Start, s.End) })
+				continue
+			}
+			for t := s.Start; t.Before(s.End); {
+				bstart, bend := bs.Bucket(t)
+				end := s.End
+				if !bend.IsZero() && bend.Before(end) {
+					end = bend
+				}
+				c.forTask(s.Task, dim, bstart, func(cl *Cell) { cl.add(s, t, end) })
+				t = end
+			}
+		}
+	}
+	return c
+}
+
+func (c *Cube) forTask(t *Task, dim int, bucket time.Time, do func(*Cell)) {
+	key := cellKey{task: t, dim: dim, bucket: bucketKey(bucket)}
+	do(c.cell(key))
+	key.sub = true
+	for t != nil {
+		key.task = t
+		do(c.cell(key))
+		t = t.parent
+	}
+}
+
+func (c *Cube) cell(key cellKey) *Cell {
+	res := c.cells[key]
+	if res == nil {
+		res = new(Cell)
+		c.cells[key] = res
+	}
+	return res
+}
+
+func bucketKey(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Dims returns the number of dimensions of the cube.
+func (c *Cube) Dims() int { return len(c.dims) }
+
+// Bucket returns the bucket of dimension dim that contains t.
+func (c *Cube) Bucket(dim int, t time.Time) (start, end time.Time) {
+	return c.dims[dim].Bucket(t)
+}
+
+// Get returns the cell of task t in the bucket of dimension dim that contains
+// at. If sub is true, the cell holds the time of t's whole subtree. The nil
+// task has no subtasks.
+func (c *Cube) Get(t *Task, sub bool, dim int, at time.Time) Cell {
+	bstart, _ := c.dims[dim].Bucket(at)
+	key := cellKey{
+		task:   t,
+		sub:    sub && t != nil,
+		dim:    dim,
+		bucket: bucketKey(bstart),
+	}
+	if res := c.cells[key]; res != nil {
+		return *res
+	}
+	return Cell{}
+}
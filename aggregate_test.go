@@ -0,0 +1,55 @@
+package tiktak
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/catch"
+)
+
+func ExampleAggregate() {
+	root := new(Task)
+	a := catch.MustRet(root.Get("a"))
+	ab := catch.MustRet(root.Get("a", "b"))
+	var tl TimeLine
+	t := time.Date(2023, time.April, 1, 22, 0, 0, 0, time.UTC)
+	tl.Switch(t, a)
+	tl.Switch(t.Add(time.Hour), ab)
+	tl.Switch(t.Add(3*time.Hour), nil)
+	cube := Aggregate(tl, time.Time{}, time.Time{}, time.Time{},
+		DayBuckets{Location: time.UTC},
+		TotalBucket{},
+	)
+	fmt.Println(cube.Get(a, false, 0, t).Duration, cube.Get(a, true, 0, t).Duration)
+	fmt.Println(cube.Get(ab, false, 0, t.Add(3*time.Hour)).Duration)
+	fmt.Println(cube.Get(a, true, 1, t).Duration)
+	// Output:
+	// 1h0m0s 2h0m0s
+	// 1h0m0s
+	// 3h0m0s
+}
+
+func TestAggregate_duration(t *testing.T) {
+	now, d, tl, ts := testTL(-5, -3, 1, 4, 7)
+	tl.Switch(now.Add(2*d), nil)
+	now = now.Add(20 * d)
+	loc := time.FixedZone("test", 90*60)
+	cube := Aggregate(tl, time.Time{}, time.Time{}, now,
+		DayBuckets{Location: loc},
+		WeekBuckets{Start: time.Monday, Location: loc},
+	)
+	for day := StartDay(tl[0].When(), 0, loc); day.Before(now); day = StartDay(day, 1, loc) {
+		next := StartDay(day, 1, loc)
+		for _, task := range ts {
+			want, _, _ := tl.Duration(day, next, now, SameTask(task))
+			if got := cube.Get(task, false, 0, day).Duration; got != want {
+				t.Errorf("%s on %s: got %s, want %s", task, day, got, want)
+			}
+		}
+		want, _, _ := tl.Duration(day, next, now, AnyTask)
+		if got := cube.Get(tl.RootTask(), true, 0, day).Duration; got != want {
+			t.Errorf("any task on %s: got %s, want %s", day, got, want)
+		}
+	}
+}
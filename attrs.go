@@ -0,0 +1,174 @@
+package tiktak
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well known task attributes
+const (
+	AttrBillable = "billable"
+	AttrRate     = "rate"
+	AttrBudget   = "budget"
+	AttrClosed   = "closed"
+	AttrColor    = "color"
+)
+
+func validAttrKey(k string) error {
+	if k == "" {
+		return fmt.Errorf("empty attribute key")
+	}
+	if strings.ContainsAny(k, "=[]\"\t\n\v\f\r \x85\xA0") ||
+		strings.ContainsFunc(k, func(r rune) bool { return !strconv.IsPrint(r) }) {
+		return fmt.Errorf("invalid attribute key '%s'", k)
+	}
+	return nil
+}
+
+// Attr returns the value of attribute key of t and whether it is set.
+func (t *Task) Attr(key string) (string, bool) {
+	v, ok := t.attrs[key]
+	return v, ok
+}
+
+// InheritedAttr returns the value of attribute key from t or from its
+// closest ancestor that has the attribute.
+func (t *Task) InheritedAttr(key string) (string, bool) {
+	for t != nil {
+		if v, ok := t.attrs[key]; ok {
+			return v, true
+		}
+		t = t.parent
+	}
+	return "", false
+}
+
+func (t *Task) SetAttr(key, value string) error {
+	if err := validAttrKey(key); err != nil {
+		return err
+	}
+	if t.attrs == nil {
+		t.attrs = make(map[string]string)
+	}
+	t.attrs[key] = value
+	return nil
+}
+
+func (t *Task) DelAttr(key string) { delete(t.attrs, key) }
+
+// AttrKeys returns the sorted keys of all attributes of t.
+func (t *Task) AttrKeys() []string {
+	keys := make([]string, 0, len(t.attrs))
+	for k := range t.attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Billable reports whether t or its closest ancestor with the billable
+// attribute is billable.
+func (t *Task) Billable() bool {
+	v, _ := t.InheritedAttr(AttrBillable)
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// Rate returns the rate of t, which is inherited from t's ancestors.
+func (t *Task) Rate() (float64, bool) {
+	v, ok := t.InheritedAttr(AttrRate)
+	if !ok {
+		return 0, false
+	}
+	r, err := strconv.ParseFloat(v, 64)
+	return r, err == nil
+}
+
+// Budget returns the time budget of t. Budgets are not inherited.
+func (t *Task) Budget() (time.Duration, bool) {
+	v, ok := t.Attr(AttrBudget)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil
+}
+
+// Closed reports whether t or its closest ancestor with the closed attribute
+// is closed.
+func (t *Task) Closed() bool {
+	v, _ := t.InheritedAttr(AttrClosed)
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// Color returns the display color of t, which is inherited from t's
+// ancestors.
+func (t *Task) Color() string {
+	v, _ := t.InheritedAttr(AttrColor)
+	return v
+}
+
+func (t *Task) appendAttrs(b []byte) []byte {
+	if len(t.attrs) == 0 {
+		return b
+	}
+	b = append(b, '[')
+	for i, k := range t.AttrKeys() {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, k...)
+		b = append(b, '=')
+		v := t.attrs[k]
+		if v == "" || strings.ContainsAny(v, "]\" \t") ||
+			strings.ContainsFunc(v, func(r rune) bool { return !strconv.IsPrint(r) }) {
+			b = strconv.AppendQuote(b, v)
+		} else {
+			b = append(b, v...)
+		}
+	}
+	return append(b, ']')
+}
+
+// parseAttrs parses the attribute list at the start of s that is enclosed in
+// square brackets and returns the rest of s.
+func (t *Task) parseAttrs(s string) (string, error) {
+	s = s[1:]
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return "", fmt.Errorf("unterminated attribute list of task %s", t)
+		}
+		if s[0] == ']' {
+			return s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return "", fmt.Errorf("attribute without value for task %s", t)
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		var val string
+		if s != "" && s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return "", fmt.Errorf("attribute '%s' of task %s: %w", key, t, err)
+			}
+			s = s[len(q):]
+			val, _ = strconv.Unquote(q)
+		} else {
+			end := strings.IndexAny(s, " \t]")
+			if end < 0 {
+				end = len(s)
+			}
+			val, s = s[:end], s[end:]
+		}
+		if err := t.SetAttr(key, val); err != nil {
+			return "", err
+		}
+	}
+}
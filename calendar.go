@@ -0,0 +1,288 @@
+package tiktak
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Weekdays is a set of weekdays.
+type Weekdays uint8
+
+// MonToFri are the weekdays Monday to Friday.
+const MonToFri Weekdays = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday |
+	1<<time.Thursday | 1<<time.Friday
+
+func WeekdaysOf(wds ...time.Weekday) (res Weekdays) {
+	for _, wd := range wds {
+		res |= 1 << wd
+	}
+	return res
+}
+
+func (wds Weekdays) Has(wd time.Weekday) bool { return wds&(1<<wd) != 0 }
+
+// HolidayRule computes the date of a holiday in a year.
+type HolidayRule interface {
+	Date(year int) (time.Month, int)
+}
+
+// FixedDate is a holiday on the same day every year.
+type FixedDate struct {
+	Month time.Month
+	Day   int
+}
+
+func (d FixedDate) Date(int) (time.Month, int) { return d.Month, d.Day }
+
+// EasterOffset is a holiday the given number of days after Easter Sunday.
+type EasterOffset int
+
+func (e EasterOffset) Date(year int) (time.Month, int) {
+	m, d := Easter(year)
+	t := time.Date(year, m, d+int(e), 0, 0, 0, 0, time.UTC)
+	return t.Month(), t.Day()
+}
+
+// WeekdayBefore is the last Weekday before Month and Day, e.g. the German
+// Buß- und Bettag is the last Wednesday before November 23rd.
+type WeekdayBefore struct {
+	Weekday time.Weekday
+	Month   time.Month
+	Day     int
+}
+
+func (w WeekdayBefore) Date(year int) (time.Month, int) {
+	t := time.Date(year, w.Month, w.Day-1, 0, 0, 0, 0, time.UTC)
+	t = LastDay(w.Weekday, t, nil)
+	return t.Month(), t.Day()
+}
+
+// Easter returns the date of Easter Sunday in the Gregorian calendar.
+func Easter(year int) (time.Month, int) {
+	// Anonymous Gregorian algorithm (Meeus/Jones/Butcher)
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Month(month), day
+}
+
+type Holiday struct {
+	Name string
+	Rule HolidayRule
+	// Since is the first year of the holiday. Zero means always.
+	Since int
+}
+
+// Closure is a range of days From–To, both inclusive, on which one does not
+// work, e.g. company holidays.
+type Closure struct {
+	From, To Date
+	Name     string
+}
+
+// Calendar tells which days are workdays. Days are computed in Location, nil
+// means the location of the time argument.
+type Calendar struct {
+	// Workdays are the weekly workdays. Zero means Monday to Friday.
+	Workdays Weekdays
+	Holidays []Holiday
+	Closures []Closure
+	Location *time.Location
+}
+
+// Holiday returns the name of the holiday or closure on the day of t.
+func (c *Calendar) Holiday(t time.Time) (name string, ok bool) {
+	d := DateIn(t, c.Location)
+	for _, h := range c.Holidays {
+		if h.Since > d.Year {
+			continue
+		}
+		if m, day := h.Rule.Date(d.Year); m == d.Month && day == d.Day {
+			return h.Name, true
+		}
+	}
+	for _, cl := range c.Closures {
+		if d.Compare(&cl.From) >= 0 && d.Compare(&cl.To) <= 0 {
+			return cl.Name, true
+		}
+	}
+	return "", false
+}
+
+// WorkWeekday reports whether wd is one of the weekly workdays.
+func (c *Calendar) WorkWeekday(wd time.Weekday) bool {
+	if c.Workdays == 0 {
+		return MonToFri.Has(wd)
+	}
+	return c.Workdays.Has(wd)
+}
+
+// Workday reports whether the day of t is a weekly workday and no holiday.
+func (c *Calendar) Workday(t time.Time) bool {
+	t, _ = inLoc(t, c.Location)
+	if !c.WorkWeekday(t.Weekday()) {
+		return false
+	}
+	_, hday := c.Holiday(t)
+	return !hday
+}
+
+// CountWorkdays returns the number of workdays from the day of from to the
+// day before to.
+func (c *Calendar) CountWorkdays(from, to time.Time) (n int) {
+	for d := StartDay(from, 0, c.Location); d.Before(to); d = StartDay(d, 1, c.Location) {
+		if c.Workday(d) {
+			n++
+		}
+	}
+	return n
+}
+
+// ParseHoliday parses a fixed-date holiday "mm-dd name".
+func ParseHoliday(s string) (Holiday, error) {
+	ds, name, _ := strings.Cut(strings.TrimSpace(s), " ")
+	t, err := time.Parse("01-02", ds)
+	if err != nil {
+		return Holiday{}, fmt.Errorf("invalid holiday '%s', expect 'mm-dd name'", s)
+	}
+	return Holiday{
+		Name: strings.TrimSpace(name),
+		Rule: FixedDate{Month: t.Month(), Day: t.Day()},
+	}, nil
+}
+
+// ReadClosures reads closures, one per line:
+//
+//	yyyy-mm-dd [name]
+//	yyyy-mm-dd..yyyy-mm-dd [name]
+//
+// Empty lines and lines starting with '#' are ignored.
+func ReadClosures(r io.Reader) (res []Closure, err error) {
+	scn := bufio.NewScanner(r)
+	lno := 0
+	for scn.Scan() {
+		lno++
+		line := strings.TrimSpace(scn.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		days, name, _ := strings.Cut(line, " ")
+		fs, ts, rng := strings.Cut(days, "..")
+		if !rng {
+			ts = fs
+		}
+		from, err := time.Parse(time.DateOnly, fs)
+		if err != nil {
+			return res, &ReadError{Line: lno, Col: 1, Err: err}
+		}
+		to, err := time.Parse(time.DateOnly, ts)
+		if err != nil {
+			return res, &ReadError{Line: lno, Col: len(fs) + 3, Err: err}
+		}
+		if to.Before(from) {
+			return res, &ReadError{Line: lno, Col: 1, Err: errors.New("closure ends before it starts")}
+		}
+		res = append(res, Closure{
+			From: DateOf(from),
+			To:   DateOf(to),
+			Name: strings.TrimSpace(name),
+		})
+	}
+	return res, scn.Err()
+}
+
+// Holiday regions are ISO 3166-2 codes, e.g. DE-BY for Bavaria.
+var holidayRegions = map[string][]Holiday{
+	"DE": {
+		{Name: "Neujahr", Rule: FixedDate{time.January, 1}},
+		{Name: "Karfreitag", Rule: EasterOffset(-2)},
+		{Name: "Ostermontag", Rule: EasterOffset(1)},
+		{Name: "Tag der Arbeit", Rule: FixedDate{time.May, 1}},
+		{Name: "Christi Himmelfahrt", Rule: EasterOffset(39)},
+		{Name: "Pfingstmontag", Rule: EasterOffset(50)},
+		{Name: "Tag der Deutschen Einheit", Rule: FixedDate{time.October, 3}},
+		{Name: "1. Weihnachtstag", Rule: FixedDate{time.December, 25}},
+		{Name: "2. Weihnachtstag", Rule: FixedDate{time.December, 26}},
+	},
+}
+
+var (
+	deDreiKoenige   = Holiday{Name: "Heilige Drei Könige", Rule: FixedDate{time.January, 6}}
+	deFronleichnam  = Holiday{Name: "Fronleichnam", Rule: EasterOffset(60)}
+	deAllerheiligen = Holiday{Name: "Allerheiligen", Rule: FixedDate{time.November, 1}}
+	deReformation   = Holiday{Name: "Reformationstag", Rule: FixedDate{time.October, 31}}
+)
+
+func init() {
+	de := holidayRegions["DE"]
+	state := func(code string, hs ...Holiday) {
+		holidayRegions["DE-"+code] = append(slices.Clone(de), hs...)
+	}
+	state("BW", deDreiKoenige, deFronleichnam, deAllerheiligen)
+	state("BY", deDreiKoenige, deFronleichnam, deAllerheiligen)
+	state("BE", Holiday{Name: "Internationaler Frauentag", Rule: FixedDate{time.March, 8}, Since: 2019})
+	state("BB",
+		Holiday{Name: "Ostersonntag", Rule: EasterOffset(0)},
+		Holiday{Name: "Pfingstsonntag", Rule: EasterOffset(49)},
+		deReformation,
+	)
+	state("HB", Holiday{Name: deReformation.Name, Rule: deReformation.Rule, Since: 2018})
+	state("HH", Holiday{Name: deReformation.Name, Rule: deReformation.Rule, Since: 2018})
+	state("HE", deFronleichnam)
+	state("MV",
+		Holiday{Name: "Internationaler Frauentag", Rule: FixedDate{time.March, 8}, Since: 2023},
+		deReformation,
+	)
+	state("NI", Holiday{Name: deReformation.Name, Rule: deReformation.Rule, Since: 2018})
+	state("NW", deFronleichnam, deAllerheiligen)
+	state("RP", deFronleichnam, deAllerheiligen)
+	state("SL",
+		deFronleichnam,
+		Holiday{Name: "Mariä Himmelfahrt", Rule: FixedDate{time.August, 15}},
+		deAllerheiligen,
+	)
+	state("SN",
+		deReformation,
+		Holiday{Name: "Buß- und Bettag", Rule: WeekdayBefore{time.Wednesday, time.November, 23}},
+	)
+	state("ST", deDreiKoenige, deReformation)
+	state("SH", Holiday{Name: deReformation.Name, Rule: deReformation.Rule, Since: 2018})
+	state("TH",
+		Holiday{Name: "Weltkindertag", Rule: FixedDate{time.September, 20}, Since: 2019},
+		deReformation,
+	)
+}
+
+// RegionHolidays returns the public holidays of a region given as ISO 3166-2
+// code, e.g. DE or DE-BY. Holidays that are only observed in parts of a
+// region are not included.
+func RegionHolidays(region string) ([]Holiday, error) {
+	hs, ok := holidayRegions[strings.ToUpper(region)]
+	if !ok {
+		return nil, fmt.Errorf("unknown holiday region '%s'", region)
+	}
+	return slices.Clone(hs), nil
+}
+
+// HolidayRegions returns the codes of all known holiday regions.
+func HolidayRegions() []string {
+	res := make([]string, 0, len(holidayRegions))
+	for r := range holidayRegions {
+		res = append(res, r)
+	}
+	slices.Sort(res)
+	return res
+}
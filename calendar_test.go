@@ -0,0 +1,89 @@
+package tiktak
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleEaster() {
+	for _, y := range []int{1818, 2000, 2024, 2025, 2285} {
+		m, d := Easter(y)
+		fmt.Println(y, m, d)
+	}
+	// Output:
+	// 1818 March 22
+	// 2000 April 23
+	// 2024 March 31
+	// 2025 April 20
+	// 2285 March 22
+}
+
+func ExampleCalendar() {
+	hs, _ := RegionHolidays("DE-SN")
+	cal := Calendar{Holidays: hs}
+	for d := time.Date(2024, time.November, 18, 12, 0, 0, 0, time.UTC); d.Day() < 25; d = AddDay(d, 1, nil) {
+		name, _ := cal.Holiday(d)
+		fmt.Printf("%s %t [%s]\n", d.Format("Mon 02"), cal.Workday(d), name)
+	}
+	// Output:
+	// Mon 18 true []
+	// Tue 19 true []
+	// Wed 20 false [Buß- und Bettag]
+	// Thu 21 true []
+	// Fri 22 true []
+	// Sat 23 false []
+	// Sun 24 false []
+}
+
+func TestCalendar_regions(t *testing.T) {
+	fronleichnam := time.Date(2024, time.May, 30, 0, 0, 0, 0, time.UTC)
+	for _, r := range HolidayRegions() {
+		hs, err := RegionHolidays(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cal := Calendar{Holidays: hs}
+		if cal.Workday(time.Date(2024, time.October, 3, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: 3rd October is a workday", r)
+		}
+		_, fronl := cal.Holiday(fronleichnam)
+		if want := slices.Contains(strings.Fields("DE-BW DE-BY DE-HE DE-NW DE-RP DE-SL"), r); fronl != want {
+			t.Errorf("%s: Fronleichnam is holiday %t", r, fronl)
+		}
+	}
+	if _, err := RegionHolidays("XX"); err == nil {
+		t.Error("unknown region without error")
+	}
+}
+
+func TestCalendar_closures(t *testing.T) {
+	cls, err := ReadClosures(strings.NewReader(`# Closures
+2024-12-27..2024-12-31 Betriebsferien
+
+2025-01-02
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := Calendar{
+		Workdays: WeekdaysOf(time.Monday, time.Tuesday, time.Wednesday, time.Thursday),
+		Closures: cls,
+	}
+	n := cal.CountWorkdays(
+		time.Date(2024, time.December, 23, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.January, 6, 0, 0, 0, 0, time.UTC),
+	)
+	// Mo 23, Tu 24, We 25, Th 26, We 1
+	if n != 5 {
+		t.Errorf("got %d workdays, want 5", n)
+	}
+	if name, _ := cal.Holiday(time.Date(2024, time.December, 30, 8, 0, 0, 0, time.UTC)); name != "Betriebsferien" {
+		t.Errorf("closure name '%s'", name)
+	}
+	if _, err := ReadClosures(strings.NewReader("2024-12-31..2024-12-27\n")); err == nil {
+		t.Error("reversed closure without error")
+	}
+}
@@ -0,0 +1,185 @@
+package tiktak
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type AnomalyKind int
+
+const (
+	// BrokenLink: The next link of a switch does not point to the
+	// following switch in the time line.
+	BrokenLink AnomalyKind = iota + 1
+	// BrokenBackRef: The task of a switch does not know the switch.
+	BrokenBackRef
+	// UnorderedSwitch: A switch happens before its predecessor.
+	UnorderedSwitch
+	// DuplicateTime: Two switches happen at the same time.
+	DuplicateTime
+	// RepeatedTask: A switch does not change the running task.
+	RepeatedTask
+	// StopWithoutGap: A switch follows a stop at the same time.
+	StopWithoutGap
+	// LeadingStop: The time line starts with a stop.
+	LeadingStop
+	// StrayNotes: Notes of a switch that will be dropped or moved to another
+	// switch on normalization.
+	StrayNotes
+)
+
+func (k AnomalyKind) String() string {
+	switch k {
+	case BrokenLink:
+		return "broken link"
+	case BrokenBackRef:
+		return "broken task reference"
+	case UnorderedSwitch:
+		return "unordered switch"
+	case DuplicateTime:
+		return "duplicate time"
+	case RepeatedTask:
+		return "repeated task"
+	case StopWithoutGap:
+		return "stop without gap"
+	case LeadingStop:
+		return "leading stop"
+	case StrayNotes:
+		return "stray notes"
+	}
+	return fmt.Sprintf("anomaly#%d", int(k))
+}
+
+// Anomaly is a problem found by [TimeLine.Validate].
+type Anomaly struct {
+	Kind AnomalyKind
+	// Index of the affected switch in the time line
+	Index int
+	// Line the affected switch was read from, 0 if unknown
+	Line int
+	Msg  string
+}
+
+func (a Anomaly) String() string {
+	if a.Line > 0 {
+		return fmt.Sprintf("%d:%s: %s", a.Line, a.Kind, a.Msg)
+	}
+	return fmt.Sprintf("#%d:%s: %s", a.Index, a.Kind, a.Msg)
+}
+
+// Validate reports all anomalies of tl. A time line built with
+// [TimeLine.Switch] has none of these anomalies. They are expected in time
+// lines read with [ReadRaw], where Validate reports what [Read] would
+// otherwise fold in silently.
+func (tl TimeLine) Validate() (as []Anomaly) {
+	add := func(k AnomalyKind, i int, format string, args ...any) {
+		as = append(as, Anomaly{
+			Kind:  k,
+			Index: i,
+			Line:  tl[i].line,
+			Msg:   fmt.Sprintf(format, args...),
+		})
+	}
+	for i, s := range tl {
+		if t := s.Task(); t != nil && !slices.Contains(t.starts, s) {
+			add(BrokenBackRef, i, "task %s does not reference switch at %s",
+				t,
+				s.When().Format(IOTimeFmt),
+			)
+		}
+		if i == 0 {
+			if s.Task() == nil {
+				add(LeadingStop, i, "stop at %s before first task",
+					s.When().Format(IOTimeFmt),
+				)
+				if len(s.notes) > 0 {
+					add(StrayNotes, i, "%d notes of leading stop get lost", len(s.notes))
+				}
+			}
+			continue
+		}
+		p := tl[i-1]
+		if p.next != s {
+			add(BrokenLink, i-1, "switch at %s does not link to next switch",
+				p.When().Format(IOTimeFmt),
+			)
+		}
+		redundant := true
+		switch {
+		case s.When().Before(p.When()):
+			add(UnorderedSwitch, i, "switch at %s before previous switch at %s",
+				s.When().Format(IOTimeFmt),
+				p.When().Format(IOTimeFmt),
+			)
+			redundant = false
+		case s.When().Equal(p.When()):
+			if p.Task() == nil {
+				add(StopWithoutGap, i, "switch to %s at same time as stop",
+					s.Task(),
+				)
+			} else {
+				add(DuplicateTime, i, "switch to %s replaces switch to %s at %s",
+					s.Task(),
+					p.Task(),
+					s.When().Format(IOTimeFmt),
+				)
+			}
+		case s.Task() == p.Task():
+			if s.Task() == nil {
+				add(RepeatedTask, i, "repeated stop")
+			} else {
+				add(RepeatedTask, i, "repeated switch to %s", s.Task())
+			}
+		default:
+			redundant = false
+		}
+		if redundant && len(s.notes) > 0 {
+			add(StrayNotes, i, "%d notes move to other switch", len(s.notes))
+		}
+	}
+	if l := len(tl); l > 0 && tl[l-1].next != nil {
+		add(BrokenLink, l-1, "last switch has next link")
+	}
+	return as
+}
+
+// Normalize builds a proper time line from the switches of tl in their order
+// in tl. This is what [Read] does with the switches of its input. Notes of
+// switches that are merged go to the resulting switch. Duplicate notes are
+// dropped. Normalize invalidates tl.
+func (tl TimeLine) Normalize() (res TimeLine) {
+	for _, s := range tl {
+		if s.to != nil {
+			s.to.rmStart(s)
+		}
+	}
+	for _, s := range tl {
+		i := res.Switch(s.when, s.to)
+		if i < 0 {
+			continue
+		}
+		ns := res[i]
+		if ns.line == 0 {
+			ns.line = s.line
+		}
+		for _, n := range s.notes {
+			if !slices.Contains(ns.notes, n) {
+				ns.notes = append(ns.notes, n)
+			}
+		}
+	}
+	return res
+}
+
+func (tl *TimeLine) appendRaw(at time.Time, to *Task, line int) int {
+	s := &Switch{to: to, when: at, line: line}
+	if l := len(*tl); l > 0 {
+		(*tl)[l-1].next = s
+	}
+	*tl = append(*tl, s)
+	if to != nil {
+		to.addStart(s)
+	}
+	return len(*tl) - 1
+}
@@ -0,0 +1,43 @@
+package tiktak
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func ExampleTimeLine_Validate() {
+	tl, err := ReadRaw(strings.NewReader(`2023-04-01T10:00:00Z
+2023-04-01T12:00:00Z /1
+2023-04-01T13:00:00Z /1
+	. Note on repeated task
+2023-04-01T14:00:00Z
+2023-04-01T14:00:00Z /2
+2023-04-01T13:30:00Z /3`), nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, a := range tl.Validate() {
+		fmt.Println(a)
+	}
+	tl = tl.Normalize()
+	fmt.Println(len(tl.Validate()))
+	Write(os.Stdout, tl)
+	// Output:
+	// 1:leading stop: stop at 2023-04-01T10:00:00Z before first task
+	// 3:repeated task: repeated switch to /1
+	// 3:stray notes: 1 notes move to other switch
+	// 6:stop without gap: switch to /2 at same time as stop
+	// 7:unordered switch: switch at 2023-04-01T13:30:00Z before previous switch at 2023-04-01T14:00:00Z
+	// 0
+	// v1.4.0	tiktak time tracker
+	// /1
+	// /2
+	// /3
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T12:00:00Z /1
+	// 	. Note on repeated task
+	// 2023-04-01T13:30:00Z /3
+	// 2023-04-01T14:00:00Z /2
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Data files that are no longer changed can be compressed with gzip to
+// <file>.gz or packed by year into the archive <yyyy>.tiktak.tar.gz. Reading
+// data files falls back to the compressed file and to the archive member
+// with the same base name.
+
+const (
+	GzipExt    = ".gz"
+	ArchiveExt = DataFileExt + ".tar.gz"
+)
+
+// ArchiveFile returns the name of the archive of year in dir.
+func ArchiveFile(dir string, year int) string {
+	return filepath.Join(dir, fmt.Sprintf("%04d%s", year, ArchiveExt))
+}
+
+// Decompress returns a reader of the data from r that transparently
+// decompresses gzip compressed data.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// readRaw reads file, its gzip compressed version or its archive member.
+func readRaw(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if !errors.Is(err, os.ErrNotExist) {
+		return data, err
+	}
+	if data, gerr := readGzip(file + GzipExt); !errors.Is(gerr, os.ErrNotExist) {
+		return data, gerr
+	}
+	if adata, aerr := readMember(file); aerr == nil {
+		return adata, nil
+	} else if !errors.Is(aerr, os.ErrNotExist) {
+		return nil, aerr
+	}
+	return nil, err
+}
+
+func readGzip(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return io.ReadAll(zr)
+}
+
+func archiveOf(file string) (string, bool) {
+	base := filepath.Base(file)
+	var year int
+	if _, err := fmt.Sscanf(base, "%4d-", &year); err != nil {
+		return "", false
+	}
+	return ArchiveFile(filepath.Dir(file), year), true
+}
+
+// readMember reads the archive member of file
+func readMember(file string) (data []byte, err error) {
+	af, ok := archiveOf(file)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	err = readArchive(af, func(name string, r io.Reader) (bool, error) {
+		if name != filepath.Base(file) {
+			return false, nil
+		}
+		data, err = io.ReadAll(r)
+		return true, err
+	})
+	if err == nil && data == nil {
+		return nil, fmt.Errorf("%s: %s: %w", af, filepath.Base(file), os.ErrNotExist)
+	}
+	return data, err
+}
+
+// readArchive calls do for each member of the archive file until do is done.
+func readArchive(file string, do func(name string, r io.Reader) (done bool, err error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if done, err := do(hdr.Name, tr); err != nil {
+			return fmt.Errorf("%s: %s: %w", file, hdr.Name, err)
+		} else if done {
+			return nil
+		}
+	}
+}
+
+// Exists reports whether the data file exists, compressed or in an archive.
+func Exists(file string) bool {
+	if _, err := os.Stat(file); err == nil {
+		return true
+	}
+	if _, err := os.Stat(file + GzipExt); err == nil {
+		return true
+	}
+	_, err := readMember(file)
+	return err == nil
+}
+
+// Archived reports whether the data file only exists compressed or in an
+// archive. Archived data files are read-only.
+func Archived(file string) bool {
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	return Exists(file)
+}
+
+// Archive packs all data files, journals and compressed data files of year in
+// dir into the archive of the year and removes them. Seal files stay in dir
+// because they chain all seals. Years that have not ended in local time
+// cannot be archived. Archive holds the lock of each month until its files
+// are removed and waits at most timeout for a lock. Archive returns the names
+// of the archived files.
+func Archive(dir string, year int, timeout time.Duration) (_ []string, err error) {
+	if end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.Local); time.Now().Before(end) {
+		return nil, fmt.Errorf("cannot archive %d before it has ended", year)
+	}
+	af := ArchiveFile(dir, year)
+	if _, err := os.Stat(af); err == nil {
+		return nil, fmt.Errorf("archive %s already exists", af)
+	}
+	var files []string
+	for _, pat := range []string{DataFileExt, DataFileExt + GzipExt, JournalExt} {
+		fs, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%04d-[0-9][0-9]%s", year, pat)))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fs...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no data files of %d in %s", year, dir)
+	}
+	slices.Sort(files)
+	var months []string
+	for _, f := range files {
+		m := filepath.Join(dir, filepath.Base(f)[:len("yyyy-mm")]+DataFileExt)
+		if !slices.Contains(months, m) {
+			months = append(months, m)
+		}
+	}
+	for _, m := range months {
+		lock, err := Acquire(m, timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if rerr := lock.Release(); err == nil {
+				err = rerr
+			}
+		}()
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	members := make(map[string][]byte)
+	for _, f := range files {
+		name := filepath.Base(f)
+		var data []byte
+		var err error
+		if filepath.Ext(name) == GzipExt {
+			name = name[:len(name)-len(GzipExt)]
+			if _, dup := members[name]; dup {
+				return nil, fmt.Errorf("%s and %s both exist", name, filepath.Base(f))
+			}
+			data, err = readGzip(f)
+		} else {
+			data, err = os.ReadFile(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		members[name] = data
+		err = tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0666,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := WriteFile(af, buf.Bytes(), 0); err != nil {
+		return nil, err
+	}
+	err = readArchive(af, func(name string, r io.Reader) (bool, error) {
+		data, err := io.ReadAll(r)
+		if err == nil && !bytes.Equal(data, members[name]) {
+			err = errors.New("archived data differs")
+		}
+		delete(members, name)
+		return false, err
+	})
+	if err == nil && len(members) > 0 {
+		err = fmt.Errorf("%s: %d members missing", af, len(members))
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jan := "2023-01-02T08:00:00Z /a\n2023-01-02T12:00:00Z\n"
+	feb := "2023-02-01T08:00:00Z /b\n2023-02-01T10:00:00Z\n"
+	write("2023-01.tiktak", jan)
+	write("2023-01.journal", "journal\n")
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(feb))
+	zw.Close()
+	write("2023-02.tiktak.gz", gz.String())
+	write("2024-01.tiktak", "2024-01-02T08:00:00Z /c\n")
+
+	if data, err := readRaw(filepath.Join(dir, "2023-02.tiktak")); err != nil {
+		t.Fatal(err)
+	} else if string(data) != feb {
+		t.Errorf("read gzip: %q", data)
+	}
+	lock, err := Acquire(filepath.Join(dir, "2023-02.tiktak"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Archive(dir, 2023, lockPoll); err == nil {
+		t.Error("archived locked month")
+	}
+	if _, err := os.Stat(ArchiveFile(dir, 2023)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("archive of locked month written:", err)
+	}
+	lock.Release()
+	files, err := Archive(dir, 2023, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("archived %d files: %v", len(files), files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2023-01.tiktak")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("archived file not removed:", err)
+	}
+	jf := filepath.Join(dir, "2023-01.tiktak")
+	if !Exists(jf) || !Archived(jf) {
+		t.Error("archived file does not exist")
+	}
+	if Archived(filepath.Join(dir, "2024-01.tiktak")) {
+		t.Error("plain file is archived")
+	}
+	if data, _, err := ReadFile(jf); err != nil {
+		t.Fatal(err)
+	} else if string(data) != jan {
+		t.Errorf("read archive member: %q", data)
+	}
+	if _, err := readRaw(filepath.Join(dir, "2023-03.tiktak")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("missing member:", err)
+	}
+	if _, err := Archive(dir, 2023, time.Second); err == nil {
+		t.Error("archive was overwritten")
+	}
+
+	store := Store{Dir: dir, Location: time.UTC}
+	months, err := store.Months()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 3 {
+		t.Errorf("months: %v", months)
+	}
+	var root tiktak.Task
+	tl, err := store.Load(&root, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tl) != 5 {
+		t.Errorf("loaded %d switches", len(tl))
+	}
+	write(fmt.Sprintf("%04d-01.tiktak", time.Now().Year()), jan)
+	if _, err := Archive(dir, time.Now().Year(), time.Second); err == nil {
+		t.Error("archived current year")
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	const data = "2023-01-02T08:00:00Z /a\n"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(data))
+	zw.Close()
+	for _, in := range []string{data, gz.String()} {
+		r, err := Decompress(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if _, err := out.ReadFrom(r); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != data {
+			t.Errorf("decompressed %q", out.String())
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"git.fractalqb.de/fractalqb/yacfg/yasec"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted data files start with a header line
+//
+//	tiktak-encrypted v1 scrypt <N> <r> <p> <salt>
+//
+// followed by the base64 encoded nonce and secretbox of the plain data file.
+// The key is derived from a passphrase with scrypt.
+//
+// The passphrase is taken from TIKTAK_KEY, the file named by TIKTAK_KEY_FILE
+// or the yasec secret .Key of the config, in this order.
+
+const (
+	EnvTiktakKey     = "TIKTAK_KEY"
+	EnvTiktakKeyFile = "TIKTAK_KEY_FILE"
+
+	// YasecSalt is the salt of the yasec passphrase that protects the key
+	// in the config.
+	YasecSalt = "409d28c5f82c4b7ff78479f62f4a3f76923656e8a2006e8505d63ce2adad"
+
+	cryptMagic   = "tiktak-encrypted"
+	cryptVersion = "v1"
+	cryptLineLen = 76
+)
+
+var scryptN, scryptR, scryptP = 1 << 15, 8, 1
+
+// Limits of the scrypt parameters that Decrypt accepts from a header
+const (
+	scryptMaxN  = 1 << 20
+	scryptMaxRP = 32
+)
+
+var ErrNoPassphrase = fmt.Errorf("no passphrase for encrypted data files, set %s, %s or .Key in the config",
+	EnvTiktakKey,
+	EnvTiktakKeyFile,
+)
+
+// KeySource returns the passphrase if neither TIKTAK_KEY nor TIKTAK_KEY_FILE
+// is set. Commands set it to open the yasec secret of their config. Nil means
+// there is no other source.
+var KeySource func() ([]byte, error)
+
+// YasecKeySource returns a [KeySource] that opens the yasec secret key. The
+// yasec passphrase is read from the terminal on the first call only.
+func YasecKeySource(key *yasec.Secret) func() ([]byte, error) {
+	return sync.OnceValues(func() ([]byte, error) {
+		rawSalt, err := hex.DecodeString(YasecSalt)
+		if err != nil {
+			return nil, err
+		}
+		yasec.DefaultConfig.Salt = rawSalt
+		yasec.DefaultConfig.SetFromPrompt("yasec passphrase:", rawSalt)
+		k, err := key.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer k.Destroy()
+		return []byte(k.String()), nil
+	})
+}
+
+// Passphrase returns the passphrase for encrypted data files from environment
+// variable TIKTAK_KEY, from the file named by TIKTAK_KEY_FILE or from
+// [KeySource].
+func Passphrase() ([]byte, error) {
+	if k := os.Getenv(EnvTiktakKey); k != "" {
+		return []byte(k), nil
+	}
+	kf := os.Getenv(EnvTiktakKeyFile)
+	if kf == "" {
+		if KeySource != nil {
+			return KeySource()
+		}
+		return nil, ErrNoPassphrase
+	}
+	k, err := os.ReadFile(kf)
+	if err != nil {
+		return nil, err
+	}
+	k = bytes.TrimRight(k, "\r\n")
+	if len(k) == 0 {
+		return nil, fmt.Errorf("empty passphrase in %s", kf)
+	}
+	return k, nil
+}
+
+// Encrypted reports whether data is an encrypted data file.
+func Encrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(cryptMagic+" "))
+}
+
+func cryptKey(passphrase, salt []byte, n, r, p int) (*[32]byte, error) {
+	k, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	return (*[32]byte)(k), nil
+}
+
+// Encrypt encrypts the data file data with passphrase.
+func Encrypt(data, passphrase []byte) ([]byte, error) {
+	var salt [16]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return nil, err
+	}
+	key, err := cryptKey(passphrase, salt[:], scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	box := secretbox.Seal(nonce[:], data, &nonce, key)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s scrypt %d %d %d %s\n",
+		cryptMagic,
+		cryptVersion,
+		scryptN, scryptR, scryptP,
+		base64.StdEncoding.EncodeToString(salt[:]),
+	)
+	enc := base64.StdEncoding.EncodeToString(box)
+	for len(enc) > cryptLineLen {
+		buf.WriteString(enc[:cryptLineLen])
+		buf.WriteByte('\n')
+		enc = enc[cryptLineLen:]
+	}
+	buf.WriteString(enc)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts the encrypted data file data with passphrase.
+func Decrypt(data, passphrase []byte) ([]byte, error) {
+	hdr, body, _ := bytes.Cut(data, []byte{'\n'})
+	fs := strings.Fields(string(hdr))
+	switch {
+	case len(fs) != 7 || fs[0] != cryptMagic || fs[2] != "scrypt":
+		return nil, errors.New("invalid header of encrypted data")
+	case fs[1] != cryptVersion:
+		return nil, fmt.Errorf("unsupported encryption version %s", fs[1])
+	}
+	var params [3]int
+	for i := range params {
+		var err error
+		if params[i], err = strconv.Atoi(fs[3+i]); err != nil {
+			return nil, fmt.Errorf("invalid scrypt parameter '%s'", fs[3+i])
+		}
+	}
+	if n, r, p := params[0], params[1], params[2]; n < 2 || n > scryptMaxN || n&(n-1) != 0 ||
+		r < 1 || r > scryptMaxRP || p < 1 || p > scryptMaxRP || r*p > scryptMaxRP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=%d r=%d p=%d", n, r, p)
+	}
+	salt, err := base64.StdEncoding.DecodeString(fs[6])
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	box, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted data: %w", err)
+	}
+	if len(box) < 24+secretbox.Overhead {
+		return nil, errors.New("encrypted data too short")
+	}
+	key, err := cryptKey(passphrase, salt, params[0], params[1], params[2])
+	if err != nil {
+		return nil, err
+	}
+	nonce := (*[24]byte)(box[:24])
+	plain, ok := secretbox.Open(nil, box[24:], nonce, key)
+	if !ok {
+		return nil, errors.New("cannot decrypt data, wrong passphrase?")
+	}
+	return plain, nil
+}
+
+// ReadFile reads file and decrypts it with the [Passphrase] if it is
+// encrypted. If file does not exist, it is read from its compressed version
+// or its archive.
+func ReadFile(file string) (data []byte, encrypted bool, err error) {
+	if data, err = readRaw(file); err != nil || !Encrypted(data) {
+		return data, false, err
+	}
+	pass, err := Passphrase()
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", file, err)
+	}
+	if data, err = Decrypt(data, pass); err != nil {
+		return nil, true, fmt.Errorf("%s: %w", file, err)
+	}
+	return data, true, nil
+}
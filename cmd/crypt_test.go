@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	data := []byte("v1.4.0\ttiktak time tracker\n2023-04-01T12:00:00Z /ACME\n")
+	enc, err := Encrypt(data, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Encrypted(enc) || bytes.Contains(enc, []byte("ACME")) {
+		t.Fatalf("not encrypted:\n%s", enc)
+	}
+	dec, err := Decrypt(enc, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec, data) {
+		t.Errorf("decrypted:\n%s", dec)
+	}
+	if _, err := Decrypt(enc, []byte("wrong")); err == nil {
+		t.Error("decrypted with wrong passphrase")
+	}
+}
+
+func TestDecrypt_scryptParams(t *testing.T) {
+	enc, err := Encrypt([]byte("v1.4.0\ttiktak time tracker\n"), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, body, _ := bytes.Cut(enc, []byte{'\n'})
+	fs := strings.Fields(string(hdr))
+	for _, params := range [][3]string{
+		{"1048577", "8", "1"},
+		{"2097152", "8", "1"},
+		{"1", "8", "1"},
+		{"0", "8", "1"},
+		{"32768", "0", "1"},
+		{"32768", "8", "5"},
+		{"32768", "1", "-1"},
+		{"32768", "4611686018427387904", "4"},
+	} {
+		copy(fs[3:6], params[:])
+		data := fmt.Appendf(nil, "%s\n%s", strings.Join(fs, " "), body)
+		if _, err := Decrypt(data, []byte("secret")); err == nil {
+			t.Errorf("decrypted with scrypt parameters %v", params)
+		} else if !strings.Contains(err.Error(), "unsupported scrypt") {
+			t.Errorf("parameters %v: %s", params, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"git.fractalqb.de/fractalqb/tiktak"
@@ -14,14 +15,19 @@ import (
 const (
 	EnvTiktakData = "TIKTAK_DATA"
 	DataFileExt   = ".tiktak"
+	JournalExt    = ".journal"
 )
 
+// JournalFile returns the name of the journal of the data file file.
+func JournalFile(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + JournalExt
+}
+
 type Config struct{}
 
 func (*Config) DataFile(t time.Time) string {
 	y, m, _ := t.Date()
-	n := fmt.Sprintf("%04d-%02d%s", y, m, DataFileExt)
-	return TikTakFile(n)
+	return TikTakFile(monthBase(y, m))
 }
 
 func OutputBasename(tl tiktak.TimeLine, day bool) string {
@@ -82,6 +88,12 @@ func OutputBasename(tl tiktak.TimeLine, day bool) string {
 	)
 }
 
+// DataFiles returns the sorted list of all monthly data files in the tiktak
+// directory.
+func DataFiles() ([]string, error) {
+	return filepath.Glob(TikTakFile("[0-9][0-9][0-9][0-9]-[0-9][0-9]" + DataFileExt))
+}
+
 func TikTakFile(base string) string {
 	return filepath.Join(TikTakDir(), base)
 }
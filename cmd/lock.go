@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EnvTiktakLocked is set for child processes of tiktak to the data files
+// whose locks tiktak holds, separated by [os.PathListSeparator]. Child
+// processes must not acquire these locks again because they would wait for
+// their parent.
+const EnvTiktakLocked = "TIKTAK_LOCKED"
+
+// Lock is an advisory lock of a data file. It locks the sidecar file
+// <file>.lock, not the data file itself, because data files are replaced on
+// write. All tiktak tools that modify data files shall hold the lock for the
+// whole read-modify-write cycle. The lock is released when the process ends.
+type Lock struct {
+	file string
+	f    *os.File
+}
+
+// LockFile returns the name of the lock file of the data file file.
+func LockFile(file string) string { return file + ".lock" }
+
+const lockPoll = 50 * time.Millisecond
+
+// Acquire acquires the lock of file. If the lock is held by another process
+// Acquire retries until timeout is over.
+func Acquire(file string, timeout time.Duration) (*Lock, error) {
+	lf := LockFile(file)
+	f, err := os.OpenFile(lf, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		switch {
+		case err != nil:
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", lf, err)
+		case ok:
+			return &Lock{file: file, f: f}, nil
+		case !time.Now().Before(deadline):
+			f.Close()
+			return nil, fmt.Errorf("%s is locked by another process, gave up after %s (lock file %s)",
+				file,
+				timeout,
+				lf,
+			)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// LockedEnv returns the EnvTiktakLocked environment entry "name=value" for
+// child processes of the holder of locks.
+func LockedEnv(locks []*Lock) string {
+	var files []string
+	for _, l := range locks {
+		if l != nil && l.f != nil {
+			if abs, err := filepath.Abs(l.file); err == nil {
+				files = append(files, abs)
+			}
+		}
+	}
+	return EnvTiktakLocked + "=" + strings.Join(files, string(os.PathListSeparator))
+}
+
+// LockedByParent reports whether the lock of file is held by the parent
+// process according to EnvTiktakLocked.
+func LockedByParent(file string) bool {
+	env := os.Getenv(EnvTiktakLocked)
+	if env == "" {
+		return false
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(filepath.SplitList(env), abs)
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "2023-04.tiktak")
+	l, err := Acquire(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(file, lockPoll); err == nil {
+		t.Fatal("acquired lock twice")
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Acquire(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+}
+
+func TestLockedByParent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "2023-04.tiktak")
+	l, err := Acquire(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+	if LockedByParent(file) {
+		t.Error("locked by parent without environment")
+	}
+	name, value, _ := strings.Cut(LockedEnv([]*Lock{l}), "=")
+	t.Setenv(name, value)
+	if !LockedByParent(file) {
+		t.Error("not locked by parent")
+	}
+	if LockedByParent(file + ".other") {
+		t.Error("other file locked by parent")
+	}
+}
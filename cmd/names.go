@@ -16,10 +16,13 @@ func CheckTaskName(n string) error {
 		if r == '/' {
 			return fmt.Errorf("path separator '/' in task name '%s'", n)
 		}
-		if unicode.IsSpace(r) {
-			return fmt.Errorf("task name '%s' contains space rune", n)
+		if unicode.IsControl(r) {
+			return fmt.Errorf("task name %q contains control character", n)
 		}
 	}
+	if strings.TrimSpace(n) != n {
+		return fmt.Errorf("task name '%s' has leading or trailing space", n)
+	}
 	return nil
 }
 
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// BackupFile returns the name of the n-th backup of file. Backup 1 is the
+// latest.
+func BackupFile(file string, n int) string { return fmt.Sprintf("%s.%d", file, n) }
+
+// WriteFile replaces the content of file with data such that file either
+// has its old or its new content after a crash. It writes and syncs a
+// temporary file, renames it to file and syncs the directory. The old content
+// is kept in up to backups rotated backup files, see [BackupFile].
+func WriteFile(file string, data []byte, backups int) error {
+	tmp := file + "~"
+	w, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err == nil {
+		err = w.Sync()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := rotateBackups(file, backups); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(file))
+}
+
+// AppendFile appends data to the end of file and syncs file. If file does
+// not exist it is created.
+func AppendFile(file string, data []byte) error {
+	w, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err == nil {
+		err = w.Sync()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func rotateBackups(file string, backups int) error {
+	if backups <= 0 {
+		return nil
+	}
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	for i := backups - 1; i > 0; i-- {
+		err := os.Rename(BackupFile(file, i), BackupFile(file, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	bak := BackupFile(file, 1)
+	os.Remove(bak)
+	if os.Link(file, bak) == nil {
+		return nil
+	}
+	return copyFile(file, bak)
+}
+
+func copyFile(from, to string) error {
+	r, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err == nil {
+		err = w.Sync()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// syncDir makes renames in dir durable. Directories cannot be synced on
+// Windows.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile_backups(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "2023-04.tiktak")
+	for _, data := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		if err := WriteFile(file, []byte(data), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AppendFile(file, []byte("5\n")); err != nil {
+		t.Fatal(err)
+	}
+	for f, expect := range map[string]string{
+		file:                "4\n5\n",
+		BackupFile(file, 1): "3\n",
+		BackupFile(file, 2): "2\n",
+	} {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expect {
+			t.Errorf("%s: expect '%s', got '%s'", f, expect, data)
+		}
+	}
+	if _, err := os.Stat(BackupFile(file, 3)); !os.IsNotExist(err) {
+		t.Errorf("unexpected third backup: %v", err)
+	}
+}
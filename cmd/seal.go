@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// A sealed data file has a seal file next to it that contains the hash of
+// the data, the hash of the previous seal in the same directory and an
+// ed25519 signature of both:
+//
+//	tiktak-seal v1 <data file>
+//	data <blake2b-256 of the plain data>
+//	prev <blake2b-256 of the previous seal file or '-'>
+//	key <base64 public key>
+//	sig <base64 signature of the lines above>
+//
+// Changing a sealed data file or an earlier seal breaks the seal. Sealing or
+// unsealing a month before the latest seal reseals all later seals to keep
+// the chain intact. Sealing, unsealing and resealing is logged in the seal log
+// of the directory.
+//
+// Seals are only verified against a trusted key, i.e. the local seal key or
+// the public key in the seal.pub file. Without a trusted key a seal is
+// unverified.
+
+const (
+	sealMagic   = "tiktak-seal"
+	sealVersion = "v1"
+	SealExt     = ".seal"
+	SealKeyFile = "seal.key"
+	SealPubFile = "seal.pub"
+	SealLogFile = "seal.log"
+)
+
+var (
+	ErrSealBroken     = errors.New("seal is broken")
+	ErrSealUnverified = errors.New("seal is unverified, no trusted key")
+)
+
+// SealFile returns the name of the seal file of the data file file.
+func SealFile(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + SealExt
+}
+
+// Sealed reports whether the data file file has a seal.
+func Sealed(file string) bool {
+	_, err := os.Stat(SealFile(file))
+	return err == nil
+}
+
+// SealKey returns the signing key from the tiktak directory. If there is no
+// key and create is set, a new key is created.
+func SealKey(create bool) (ed25519.PrivateKey, error) {
+	kf := TikTakFile(SealKeyFile)
+	data, err := os.ReadFile(kf)
+	switch {
+	case err == nil:
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid seal key in %s", kf)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	case !errors.Is(err, os.ErrNotExist) || !create:
+		return nil, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	data = []byte(base64.StdEncoding.EncodeToString(key.Seed()) + "\n")
+	if err := os.WriteFile(kf, data, 0600); err != nil {
+		return nil, err
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	data = []byte(base64.StdEncoding.EncodeToString(pub) + "\n")
+	if err := os.WriteFile(TikTakFile(SealPubFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// TrustedSealKey returns the public key of the local seal key or, if there
+// is none, the public key from the seal.pub file, e.g. to verify seals on
+// another machine. If there is neither, the error is [os.ErrNotExist].
+func TrustedSealKey() (ed25519.PublicKey, error) {
+	key, err := SealKey(false)
+	switch {
+	case err == nil:
+		return key.Public().(ed25519.PublicKey), nil
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	pf := TikTakFile(SealPubFile)
+	data, err := os.ReadFile(pf)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public seal key in %s", pf)
+	}
+	return pub, nil
+}
+
+// prevSeal returns the content of the latest seal file before the seal of
+// file in the same directory.
+func prevSeal(file string) ([]byte, error) {
+	sf := SealFile(file)
+	seals, err := filepath.Glob(filepath.Join(filepath.Dir(sf), "*"+SealExt))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(seals)
+	i, _ := slices.BinarySearch(seals, sf)
+	if i == 0 {
+		return nil, nil
+	}
+	return os.ReadFile(seals[i-1])
+}
+
+// laterSeals returns the data files of the seals after the seal of file in
+// the same directory in chain order.
+func laterSeals(file string) (res []string, err error) {
+	sf := SealFile(file)
+	seals, err := filepath.Glob(filepath.Join(filepath.Dir(sf), "*"+SealExt))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(seals)
+	for _, s := range seals {
+		if s <= sf {
+			continue
+		}
+		seal, err := os.ReadFile(s)
+		if err != nil {
+			return nil, err
+		}
+		head, _, _ := strings.Cut(string(seal), "\n")
+		f := strings.Fields(head)
+		if len(f) != 3 || f[0] != sealMagic {
+			return nil, fmt.Errorf("%s: %w: invalid seal file", s, ErrSealBroken)
+		}
+		res = append(res, filepath.Join(filepath.Dir(s), f[2]))
+	}
+	return res, nil
+}
+
+func sealHash(data []byte) string {
+	h := blake2b.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func sealBody(file string, data, prev []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s\n", sealMagic, sealVersion, filepath.Base(file))
+	fmt.Fprintf(&buf, "data %s\n", sealHash(data))
+	if prev == nil {
+		buf.WriteString("prev -\n")
+	} else {
+		fmt.Fprintf(&buf, "prev %s\n", sealHash(prev))
+	}
+	return buf.Bytes()
+}
+
+// Seal seals the data file file with key and logs it. Later seals are
+// resealed.
+func Seal(file string, key ed25519.PrivateKey) error {
+	if Sealed(file) {
+		return fmt.Errorf("%s is already sealed", file)
+	}
+	later, err := checkLaterSeals(file, key)
+	if err != nil {
+		return err
+	}
+	hash, err := writeSeal(file, key)
+	if err != nil {
+		return err
+	}
+	if err := sealLog(file, "seal", hash); err != nil {
+		return err
+	}
+	return reseal(later, key)
+}
+
+func writeSeal(file string, key ed25519.PrivateKey) (hash string, err error) {
+	data, _, err := ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	prev, err := prevSeal(file)
+	if err != nil {
+		return "", err
+	}
+	seal := sealBody(file, data, prev)
+	pub := key.Public().(ed25519.PublicKey)
+	seal = fmt.Appendf(seal, "key %s\n", base64.StdEncoding.EncodeToString(pub))
+	sig := ed25519.Sign(key, seal)
+	seal = fmt.Appendf(seal, "sig %s\n", base64.StdEncoding.EncodeToString(sig))
+	if err := WriteFile(SealFile(file), seal, 0); err != nil {
+		return "", err
+	}
+	return sealHash(data), nil
+}
+
+// checkLaterSeals verifies the seals after the seal of file before they are
+// resealed with key. This keeps resealing from covering up broken seals.
+func checkLaterSeals(file string, key ed25519.PrivateKey) ([]string, error) {
+	later, err := laterSeals(file)
+	if err != nil || len(later) == 0 {
+		return later, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("need the seal key to reseal %s", later[0])
+	}
+	for _, f := range later {
+		if _, err := VerifySeal(f, key.Public().(ed25519.PublicKey)); err != nil {
+			return nil, fmt.Errorf("cannot reseal: %w", err)
+		}
+	}
+	return later, nil
+}
+
+// reseal rebuilds the seals of the data files files in chain order.
+func reseal(files []string, key ed25519.PrivateKey) error {
+	for _, f := range files {
+		hash, err := writeSeal(f, key)
+		if err != nil {
+			return err
+		}
+		if err := sealLog(f, "reseal", hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifySeal verifies the seal of the data file file. The seal must be signed
+// with the trusted key. If trusted is nil, VerifySeal only checks the hash
+// chain and returns [ErrSealUnverified] if that is intact. VerifySeal returns
+// false if file is not sealed.
+func VerifySeal(file string, trusted ed25519.PublicKey) (sealed bool, err error) {
+	seal, err := os.ReadFile(SealFile(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return true, err
+	}
+	broken := func(format string, args ...any) (bool, error) {
+		return true, fmt.Errorf("%s: %w: %s", SealFile(file), ErrSealBroken, fmt.Sprintf(format, args...))
+	}
+	lines := strings.SplitAfter(string(seal), "\n")
+	if len(lines) < 5 || !strings.HasPrefix(lines[0], sealMagic+" "+sealVersion+" ") {
+		return broken("invalid seal file")
+	}
+	pub, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(lines[3], "key ")))
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return broken("invalid key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(lines[4], "sig ")))
+	if err != nil {
+		return broken("invalid signature")
+	}
+	signed := []byte(strings.Join(lines[:4], ""))
+	switch {
+	case trusted != nil && !trusted.Equal(ed25519.PublicKey(pub)):
+		return broken("signed with an untrusted key")
+	case !ed25519.Verify(pub, signed, sig):
+		return broken("invalid signature")
+	}
+	data, _, err := ReadFile(file)
+	if err != nil {
+		return true, err
+	}
+	prev, err := prevSeal(file)
+	if err != nil {
+		return true, err
+	}
+	body := sealBody(file, data, prev)
+	if l1 := strings.SplitAfter(string(body), "\n"); l1[1] != lines[1] {
+		return broken("data was changed")
+	} else if l1[2] != lines[2] {
+		return broken("previous seal was changed")
+	}
+	if trusted == nil {
+		return true, fmt.Errorf("%s: %w", SealFile(file), ErrSealUnverified)
+	}
+	return true, nil
+}
+
+// Unseal removes the seal of the data file file and logs it with reason.
+// Later seals are resealed with key, which may be nil if there are none.
+func Unseal(file, reason string, key ed25519.PrivateKey) error {
+	later, err := checkLaterSeals(file, key)
+	if err != nil {
+		return err
+	}
+	data, _, err := ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(SealFile(file)); err != nil {
+		return err
+	}
+	if err := sealLog(file, "unseal", sealHash(data)+" "+reason); err != nil {
+		return err
+	}
+	return reseal(later, key)
+}
+
+func sealLog(file, what, text string) error {
+	line := fmt.Sprintf("%s %s %s %s\n",
+		time.Now().Round(time.Second).Format(time.RFC3339),
+		what,
+		filepath.Base(file),
+		text,
+	)
+	return AppendFile(filepath.Join(filepath.Dir(file), SealLogFile), []byte(line))
+}
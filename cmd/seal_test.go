@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	dir := t.TempDir()
+	_, key, _ := ed25519.GenerateKey(nil)
+	pub := key.Public().(ed25519.PublicKey)
+	aug, sep := filepath.Join(dir, "2023-08.tiktak"), filepath.Join(dir, "2023-09.tiktak")
+	for _, f := range []string{aug, sep} {
+		if err := os.WriteFile(f, []byte("2023-08-01T08:00:00Z /a\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := Seal(f, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sealed, err := VerifySeal(sep, pub); !sealed || err != nil {
+		t.Fatalf("verify: %t %v", sealed, err)
+	}
+	_, other, _ := ed25519.GenerateKey(nil)
+	if _, err := VerifySeal(sep, other.Public().(ed25519.PublicKey)); !errors.Is(err, ErrSealBroken) {
+		t.Errorf("untrusted key: %v", err)
+	}
+	os.WriteFile(aug, []byte("2023-08-01T09:00:00Z /a\n"), 0666)
+	if _, err := VerifySeal(aug, pub); !errors.Is(err, ErrSealBroken) {
+		t.Errorf("changed data: %v", err)
+	}
+	if _, err := VerifySeal(sep, nil); !errors.Is(err, ErrSealUnverified) {
+		t.Errorf("no trusted key: %v", err)
+	}
+	os.WriteFile(aug, []byte("2023-08-01T08:00:00Z /a\n"), 0666)
+	os.WriteFile(sep, []byte("2023-09-01T08:00:00Z /a\n"), 0666)
+	if err := Unseal(aug, "test", key); err == nil {
+		t.Error("resealed broken seal")
+	}
+	os.WriteFile(sep, []byte("2023-08-01T08:00:00Z /a\n"), 0666)
+	if err := Unseal(aug, "test", key); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, err := VerifySeal(sep, pub); !sealed || err != nil {
+		t.Errorf("resealed after unseal: %t %v", sealed, err)
+	}
+	if err := Seal(aug, key); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, err := VerifySeal(sep, pub); !sealed || err != nil {
+		t.Errorf("resealed after seal: %t %v", sealed, err)
+	}
+	log, _ := os.ReadFile(filepath.Join(dir, SealLogFile))
+	if n := strings.Count(string(log), " reseal 2023-09.tiktak "); n != 2 {
+		t.Errorf("logged %d reseals:\n%s", n, log)
+	}
+	os.Remove(SealFile(aug))
+	if _, err := VerifySeal(sep, pub); !errors.Is(err, ErrSealBroken) {
+		t.Errorf("removed previous seal: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+// Store is the set of monthly data files in directory Dir. It loads time
+// ranges that span several months into a single time line.
+type Store struct {
+	Dir string
+	// Location decides about the month a time belongs to. Nil is local
+	// time.
+	Location *time.Location
+	Lenient  bool
+	Strict   bool
+	// SealKey is the trusted key of sealed data files. With nil, seals are
+	// unverified, which is a warning.
+	SealKey ed25519.PublicKey
+	// Diagnostic is called for each diagnostic of the tiktak reader.
+	Diagnostic func(file string, d *tiktak.ReadError)
+}
+
+// NewStore returns the store of the tiktak directory.
+func NewStore(loc *time.Location) *Store {
+	return &Store{Dir: TikTakDir(), Location: loc}
+}
+
+func (s *Store) loc() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
+
+func monthBase(y int, m time.Month) string {
+	return fmt.Sprintf("%04d-%02d%s", y, m, DataFileExt)
+}
+
+// File returns the name of the data file of the month of t.
+func (s *Store) File(t time.Time) string {
+	y, m, _ := t.In(s.loc()).Date()
+	return filepath.Join(s.Dir, monthBase(y, m))
+}
+
+// Months returns the start of each month that has a data file in s in
+// ascending order. Compressed and archived data files are included.
+func (s *Store) Months() ([]time.Time, error) {
+	const month = "[0-9][0-9][0-9][0-9]-[0-9][0-9]" + DataFileExt
+	var names []string
+	for _, pat := range []string{month, month + GzipExt} {
+		files, err := filepath.Glob(filepath.Join(s.Dir, pat))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			names = append(names, filepath.Base(f))
+		}
+	}
+	archives, err := filepath.Glob(filepath.Join(s.Dir, "[0-9][0-9][0-9][0-9]"+ArchiveExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range archives {
+		err := readArchive(a, func(name string, _ io.Reader) (bool, error) {
+			names = append(names, name)
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var res []time.Time
+	for _, n := range names {
+		t, err := time.ParseInLocation("2006-01"+DataFileExt, strings.TrimSuffix(n, GzipExt), s.loc())
+		if err != nil {
+			continue
+		}
+		res = append(res, t)
+	}
+	slices.SortFunc(res, time.Time.Compare)
+	return slices.CompactFunc(res, time.Time.Equal), nil
+}
+
+// Load reads the time line from time from up to time to into the task tree
+// root. Zero from or to do not limit the range. Load also reads the latest
+// month before from to know the task running at from. A span that is open at
+// the end of a month is continued by the next month. The result is clipped
+// to the range, which stops an open span at to.
+func (s *Store) Load(root *tiktak.Task, from, to time.Time) (tl tiktak.TimeLine, err error) {
+	months, err := s.Months()
+	if err != nil {
+		return nil, err
+	}
+	first := 0
+	if !from.IsZero() {
+		start := tiktak.StartMonth(from, 0, s.loc())
+		for first < len(months) && months[first].Before(start) {
+			first++
+		}
+		if first > 0 {
+			first--
+		}
+	}
+	for _, m := range months[first:] {
+		if !to.IsZero() && !m.Before(to) {
+			break
+		}
+		mtl, err := s.readMonth(m, root)
+		if err != nil {
+			return nil, err
+		}
+		if err := tl.Append(mtl); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.File(m), err)
+		}
+	}
+	clip(&tl, from, to)
+	for _, n := range root.Tracks() {
+		clip(root.Track(n), from, to)
+	}
+	return tl, nil
+}
+
+func (s *Store) readMonth(m time.Time, root *tiktak.Task) (tiktak.TimeLine, error) {
+	file := s.File(m)
+	data, _, err := ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	rd := tiktak.Reader{Lenient: s.Lenient, Strict: s.Strict}
+	tl, err := rd.Read(bytes.NewReader(data), root)
+	if s.Diagnostic != nil {
+		for _, d := range rd.Diagnostics {
+			s.Diagnostic(file, d)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", file, err)
+	}
+	if _, err := VerifySeal(file, s.SealKey); err != nil {
+		if !s.Lenient && !errors.Is(err, ErrSealUnverified) {
+			return nil, err
+		}
+		if s.Diagnostic != nil {
+			s.Diagnostic(file, &tiktak.ReadError{Warning: true, Err: err})
+		}
+	}
+	return tl, nil
+}
+
+func clip(tl *tiktak.TimeLine, from, to time.Time) {
+	if !to.IsZero() {
+		tl.ClipAfter(to)
+	}
+	if !from.IsZero() {
+		tl.ClipBefore(from)
+	}
+}
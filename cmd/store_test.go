@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func TestStore_Load(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("2023-03.tiktak", `2023-03-31T20:00:00Z /a
+`)
+	write("2023-04.tiktak", `2023-04-01T00:00:00Z /a
+2023-04-01T02:00:00Z
+2023-04-03T08:00:00Z /b
+`)
+	write("2023-05.tiktak", `2023-05-02T08:00:00Z /a
+`)
+	store := Store{Dir: dir, Location: time.UTC}
+	var root tiktak.Task
+	tl, err := store.Load(&root,
+		time.Date(2023, 3, 31, 22, 0, 0, 0, time.UTC),
+		time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type sw struct {
+		at   string
+		task string
+	}
+	expect := []sw{
+		{"2023-03-31T22:00:00Z", "/a"},
+		{"2023-04-01T02:00:00Z", ""},
+		{"2023-04-03T08:00:00Z", "/b"},
+		{"2023-05-01T00:00:00Z", ""},
+	}
+	if len(tl) != len(expect) {
+		t.Fatalf("expect %d switches, got %d", len(expect), len(tl))
+	}
+	for i, s := range tl {
+		task := ""
+		if s.Task() != nil {
+			task = s.Task().String()
+		}
+		if at := s.When().Format(tiktak.IOTimeFmt); at != expect[i].at || task != expect[i].task {
+			t.Errorf("switch %d: expect %v, got %s %s", i, expect[i], at, task)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
 	stdFilter "git.fractalqb.de/fractalqb/tiktak/internal/filters"
 )
 
@@ -23,11 +24,24 @@ var filters = map[string]func() filter{
 func main() {
 	list := flag.Bool("l", false, "List filters")
 	nowStr := flag.String("t", "", "Set current time")
+	lockFile := flag.String("lock", "",
+		`Hold the lock of the given tiktak data file while filtering. This is
+for standalone use. The lock is not acquired again if tiktak runs tikflt
+as a filter while it holds the lock (see `+cmd.EnvTiktakLocked+`).`)
+	lockTimeout := flag.Duration("lock-timeout", 5*time.Second,
+		"Maximum time to wait for the lock")
 	flag.Parse()
 	if *list {
 		listFilters()
 		return
 	}
+	if *lockFile != "" && !cmd.LockedByParent(*lockFile) {
+		lock, err := cmd.Acquire(*lockFile, *lockTimeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer lock.Release()
+	}
 	var now time.Time
 	if *nowStr == "" {
 		now = time.Now()
@@ -37,8 +51,12 @@ func main() {
 			log.Fatalf("invalid current time '%s', expect RFC 3339 format", *nowStr)
 		}
 	}
+	in, err := cmd.Decompress(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
 	var tr tiktak.Task
-	tl, err := tiktak.Read(os.Stdin, &tr)
+	tl, err := tiktak.Read(in, &tr)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -56,7 +74,7 @@ func main() {
 			log.Fatalf("%s: %s", fname, err)
 		}
 	}
-	if err := tiktak.Write(os.Stdout, tl); err != nil {
+	if err := tiktak.WriteTree(os.Stdout, &tr, tl); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"time"
 
 	"git.fractalqb.de/fractalqb/ggja"
@@ -24,7 +28,42 @@ type inspan struct {
 
 func main() {
 	flag.BoolVar(&nameDay, "day", nameDay, "Use day in file names")
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, `Usage of %[1]s v%[2]s:
+  %[1]s [flags] [json file…]: Migrate JSON files to tiktak files
+  %[1]s merge base ours theirs: Three-way merge tiktak files into ours.
+    Conflicts are marked with warning notes. If a file is encrypted, the
+    result is encrypted too. Use as git merge driver:
+      git config merge.tiktak.driver "%[1]s merge %%O %%A %%B"
+    and in .gitattributes:
+      *.tiktak merge=tiktak
+  %[1]s encrypt file…: Encrypt data files with their journals and backups
+    with the passphrase from environment variable %[3]s, from the file
+    named by %[4]s or from the yasec secret .Key in tiktak.yaml.
+  %[1]s decrypt file…: Decrypt data files with their journals and backups.
+  %[1]s archive [-dir dir] year…: Pack the data files and journals of each
+    year into the archive yyyy%[5]s. Archived data files are read
+    transparently but cannot be changed. Only years that have ended can be
+    archived.
+`, os.Args[0], cmd.Version, cmd.EnvTiktakKey, cmd.EnvTiktakKeyFile, cmd.ArchiveExt)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	switch flag.Arg(0) {
+	case "merge":
+		merge(flag.Args()[1:])
+		return
+	case "encrypt":
+		crypt(flag.Args()[1:], true)
+		return
+	case "decrypt":
+		crypt(flag.Args()[1:], false)
+		return
+	case "archive":
+		archive(flag.Args()[1:])
+		return
+	}
 	if len(flag.Args()) == 0 {
 		tl := migrate(os.Stdin)
 		if err := tiktak.Write(os.Stdout, tl); err != nil {
@@ -37,6 +76,121 @@ func main() {
 	}
 }
 
+func merge(args []string) {
+	if len(args) != 3 {
+		log.Fatal("merge needs base, ours and theirs files")
+	}
+	var encrypted bool
+	read := func(name string) tiktak.Version {
+		data, enc, err := cmd.ReadFile(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		encrypted = encrypted || enc
+		v := tiktak.Version{Root: new(tiktak.Task)}
+		if v.TimeLine, err = tiktak.Read(bytes.NewReader(data), v.Root); err != nil {
+			log.Fatalf("%s:%s", name, err)
+		}
+		return v
+	}
+	configKeySource()
+	base, ours, theirs := read(args[0]), read(args[1]), read(args[2])
+	var root tiktak.Task
+	tl, n := tiktak.Merge3(&root, base, ours, theirs)
+	var buf bytes.Buffer
+	if err := tiktak.WriteTree(&buf, &root, tl); err != nil {
+		log.Fatal(err)
+	}
+	data := buf.Bytes()
+	if encrypted {
+		pass, err := cmd.Passphrase()
+		if err == nil {
+			data, err = cmd.Encrypt(data, pass)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := cmd.WriteFile(args[1], data, 0); err != nil {
+		log.Fatal(err)
+	}
+	if n > 0 {
+		log.Printf("%s: %d merge conflicts marked with '%c' warnings",
+			args[1],
+			n,
+			tiktak.ConflictSym,
+		)
+	}
+}
+
+func crypt(files []string, encrypt bool) {
+	configKeySource()
+	pass, err := cmd.Passphrase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, file := range files {
+		lock, err := cmd.Acquire(file, 5*time.Second)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !cryptFile(file, pass, encrypt) {
+			log.Printf("%s: skipped, nothing to do", file)
+		}
+		cryptFile(cmd.JournalFile(file), pass, encrypt)
+		for n := 1; ; n++ {
+			bak := cmd.BackupFile(file, n)
+			if _, err := os.Stat(bak); err != nil {
+				break
+			}
+			cryptFile(bak, pass, encrypt)
+		}
+		lock.Release()
+	}
+}
+
+// cryptFile encrypts or decrypts file if it exists and is not yet converted.
+// It reports whether file was converted.
+func cryptFile(file string, pass []byte, encrypt bool) bool {
+	data, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return false
+	case err != nil:
+		log.Fatal(err)
+	case cmd.Encrypted(data) == encrypt:
+		return false
+	case encrypt:
+		data, err = cmd.Encrypt(data, pass)
+	default:
+		data, err = cmd.Decrypt(data, pass)
+	}
+	if err == nil {
+		err = cmd.WriteFile(file, data, 0)
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", file, err)
+	}
+	return true
+}
+
+func archive(args []string) {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	dir := flags.String("dir", cmd.TikTakDir(), "Data directory")
+	flags.Parse(args)
+	for _, arg := range flags.Args() {
+		year, err := strconv.Atoi(arg)
+		if err != nil {
+			log.Fatalf("invalid year '%s'", arg)
+		}
+		files, err := cmd.Archive(*dir, year, 5*time.Second)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("archived %d files into %s", len(files), cmd.ArchiveFile(*dir, year))
+	}
+}
+
 func migrateFile(name string) {
 	r, err := os.Open(name)
 	if err != nil {
@@ -45,12 +199,11 @@ func migrateFile(name string) {
 	defer r.Close()
 	tl := migrate(r)
 	name = filepath.Join(filepath.Dir(name), cmd.OutputBasename(tl, nameDay))
-	w, err := os.Create(name)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := tiktak.Write(&buf, tl); err != nil {
 		log.Fatal(err)
 	}
-	defer w.Close()
-	if err := tiktak.Write(w, tl); err != nil {
+	if err := cmd.WriteFile(name, buf.Bytes(), 0); err != nil {
 		log.Fatal(err)
 	}
 }
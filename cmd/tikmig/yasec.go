@@ -0,0 +1,28 @@
+package main
+
+import (
+	"sync"
+
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+	"git.fractalqb.de/fractalqb/yacfg/yasec"
+)
+
+// configKeySource makes the yasec secret .Key of the tiktak config the last
+// source of the passphrase of encrypted data files. The config is only read
+// if a passphrase is needed.
+func configKeySource() {
+	cmd.KeySource = sync.OnceValues(func() ([]byte, error) {
+		var cfg struct {
+			TikTak struct {
+				Key *yasec.Secret
+			}
+		}
+		if err := cmd.ReadConfig(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.TikTak.Key == nil {
+			return nil, cmd.ErrNoPassphrase
+		}
+		return cmd.YasecKeySource(cfg.TikTak.Key)()
+	})
+}
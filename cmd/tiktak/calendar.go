@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+)
+
+// closuresFile lists the days without work in addition to holidays
+const closuresFile = "closures.txt"
+
+// calendarConfigured tells if the config or the closures file define a
+// working-day calendar.
+func calendarConfigured() bool {
+	cc := &cfg.TikTak.Calendar
+	if cc.Region != "" || len(cc.Holidays) > 0 || len(cc.Workdays) > 0 {
+		return true
+	}
+	_, err := os.Stat(cmd.TikTakFile(closuresFile))
+	return err == nil
+}
+
+// calendar returns the working-day calendar from the config and the closures
+// file in the data directory.
+func calendar() *tiktak.Calendar {
+	cc := &cfg.TikTak.Calendar
+	cal := &tiktak.Calendar{
+		Workdays: tiktak.WeekdaysOf(cc.Workdays...),
+		Location: home,
+	}
+	if cc.Region != "" {
+		cal.Holidays = mustRet(tiktak.RegionHolidays(cc.Region))
+	}
+	for _, h := range cc.Holidays {
+		cal.Holidays = append(cal.Holidays, mustRet(tiktak.ParseHoliday(h)))
+	}
+	cf := cmd.TikTakFile(closuresFile)
+	r, err := os.Open(cf)
+	if errors.Is(err, os.ErrNotExist) {
+		return cal
+	}
+	must(err)
+	defer r.Close()
+	if cal.Closures, err = tiktak.ReadClosures(r); err != nil {
+		must(fmt.Errorf("%s:%w", cf, err))
+	}
+	return cal
+}
+
+// showHolidays lists the holidays and closures of the year of now
+func showHolidays() {
+	cal := calendar()
+	var tbl tetrta.Table
+	crsr := tbl.At(0, 0)
+	day := time.Date(now.In(home).Year(), time.January, 1, 0, 0, 0, 0, home)
+	for end := day.AddDate(1, 0, 0); day.Before(end); day = tiktak.StartDay(day, 1, home) {
+		if name, ok := cal.Holiday(day); ok {
+			crsr.SetStrings(formats.Date(day), name).NextRow()
+		}
+	}
+	tableWr.Write(os.Stdout, &tbl)
+}
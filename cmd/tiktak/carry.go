@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+)
+
+type monthData struct {
+	file string
+	root tiktak.Task
+	tl   tiktak.TimeLine
+	// carry lists the tasks carried over into the new month
+	carry []string
+}
+
+// carried is the previous month's data if tasks that were running at its end
+// were carried over into a new month file. The spans are stopped at the month
+// boundary in the previous file when the new file is written.
+var carried *monthData
+
+// carryOver restarts the tasks that are running at the end of the previous
+// month at the start of the current month. It is used when the current
+// month's data file is created.
+func carryOver() {
+	start := tiktak.StartMonth(now, 0, home)
+	prev := monthData{file: cfg.DataFile(tiktak.StartMonth(now, -1, home))}
+	if cmd.Sealed(prev.file) || cmd.Archived(prev.file) {
+		return
+	}
+	if len(locks) > 0 {
+		lock(prev.file)
+	}
+	r, err := readData(prev.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	must(err)
+	if prev.tl, err = tiktak.Read(r, &prev.root); err != nil {
+		log.Printf("cannot carry over running tasks from %s:%s", prev.file, err)
+		return
+	}
+	carry := func(old, tl *tiktak.TimeLine, prefix string) {
+		if len(*old) == 0 {
+			return
+		}
+		last := (*old)[len(*old)-1]
+		if last.Task() == nil || !last.When().Before(start) {
+			return
+		}
+		t := mustRet(rootTask.GetString(last.Task().String()))
+		for n, o := t, last.Task(); n != nil && o != nil; n, o = n.Parent(), o.Parent() {
+			if n.Title() == "" {
+				n.SetTitle(o.Title())
+			}
+			for _, k := range o.AttrKeys() {
+				if _, ok := n.Attr(k); !ok {
+					v, _ := o.Attr(k)
+					n.SetAttr(k, v)
+				}
+			}
+		}
+		tl.Switch(start, t)
+		old.Switch(start, nil)
+		prev.carry = append(prev.carry, prefix+t.String())
+	}
+	carry(&prev.tl, &timeline, "")
+	for _, n := range prev.root.Tracks() {
+		carry(prev.root.Track(n), mustRet(rootTask.MakeTrack(n)), "@"+n+" ")
+	}
+	if len(prev.carry) > 0 {
+		carried = &prev
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+)
+
+func check(args []string) {
+	repair, dropBad := false, false
+	if len(args) > 0 {
+		if args[0] != "repair" {
+			log.Fatalf("invalid check argument '%s'", args[0])
+		}
+		repair = true
+		flags := flag.NewFlagSet("repair", flag.ExitOnError)
+		flags.BoolVar(&dropBad, "drop-bad-lines", false, "Drop unreadable lines")
+		flags.Parse(args[1:])
+		if flags.NArg() > 0 {
+			log.Fatal("too many check arguments")
+		}
+	}
+	if repair {
+		lock(file)
+	}
+	var raw tiktak.TimeLine
+	rd := tiktak.Reader{Raw: true, Lenient: true, Strict: strictRead}
+	if file == "-" {
+		raw = mustRet(rd.Read(os.Stdin, &rootTask))
+	} else {
+		r := mustRet(readData(file))
+		raw = mustRet(rd.Read(r, &rootTask))
+	}
+	for _, d := range rd.Diagnostics {
+		fmt.Printf("%s:%s\n", file, d)
+	}
+	if n := rd.Errors(); repair && n > 0 && !dropBad {
+		log.Fatalf("repair would drop %d unreadable lines, fix them or use 'repair -drop-bad-lines'", n)
+	}
+	as := raw.Validate()
+	for _, a := range as {
+		fmt.Printf("%s:%s\n", file, a)
+	}
+	count := rd.Errors() + len(as)
+	if file != "-" {
+		if _, err := cmd.VerifySeal(file, trustedKey()); err != nil {
+			fmt.Println(err)
+			if !errors.Is(err, cmd.ErrSealUnverified) {
+				count++
+			}
+		}
+	}
+	for _, n := range rootTask.Tracks() {
+		tl := rootTask.Track(n)
+		as := tl.Validate()
+		for _, a := range as {
+			fmt.Printf("%s:@%s:%s\n", file, n, a)
+		}
+		count += len(as)
+		if repair {
+			*tl = tl.Normalize()
+		}
+	}
+	if repair {
+		timeline = raw.Normalize()
+		write(file)
+		if count > 0 {
+			log.Printf("repaired %d anomalies", count)
+		}
+	} else if count > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+type jsonSwitch struct {
+	Time  time.Time `json:"time"`
+	Task  string    `json:"task,omitempty"`
+	Notes []string  `json:"notes,omitempty"`
+}
+
+type jsonChange struct {
+	Kind     tiktak.ChangeKind `json:"kind"`
+	Old      *jsonSwitch       `json:"old,omitempty"`
+	New      *jsonSwitch       `json:"new,omitempty"`
+	Task     string            `json:"task,omitempty"`
+	OldTitle string            `json:"oldTitle,omitempty"`
+	NewTitle string            `json:"newTitle,omitempty"`
+	OldNotes []string          `json:"oldNotes,omitempty"`
+	NewNotes []string          `json:"newNotes,omitempty"`
+	Day      string            `json:"day,omitempty"`
+	Attr     string            `json:"attr,omitempty"`
+	OldValue string            `json:"oldValue,omitempty"`
+	NewValue string            `json:"newValue,omitempty"`
+}
+
+func noteStrings(ns []tiktak.Note) (res []string) {
+	for _, n := range ns {
+		if n.Sym == 0 {
+			res = append(res, n.Text)
+		} else {
+			res = append(res, fmt.Sprintf("%c %s", n.Sym, n.Text))
+		}
+	}
+	return res
+}
+
+func jsonSw(s *tiktak.Switch) *jsonSwitch {
+	if s == nil {
+		return nil
+	}
+	res := &jsonSwitch{Time: s.When(), Notes: noteStrings(s.Notes())}
+	if t := s.Task(); t != nil {
+		res.Task = t.String()
+	}
+	return res
+}
+
+// diff shows the changes from the current data file to the file in args
+func diff(args []string) {
+	if len(args) != 1 {
+		log.Fatal("diff needs exactly one file argument")
+	}
+	read()
+	var other tiktak.Task
+	r := mustRet(readData(args[0]))
+	tl := mustRet(tiktak.Read(r, &other))
+	if track != "" {
+		if ttl := other.Track(track); ttl != nil {
+			tl = *ttl
+		} else {
+			tl = nil
+		}
+	}
+	changes := tiktak.Diff(&rootTask, &other, *trackLine(), tl)
+	if !jsonOut {
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		return
+	}
+	res := make([]jsonChange, len(changes))
+	for i, c := range changes {
+		res[i] = jsonChange{
+			Kind:     c.Kind,
+			Old:      jsonSw(c.A),
+			New:      jsonSw(c.B),
+			Task:     c.Task,
+			OldTitle: c.OldTitle,
+			NewTitle: c.NewTitle,
+			OldNotes: noteStrings(c.OldNotes),
+			NewNotes: noteStrings(c.NewNotes),
+			Attr:     c.Attr,
+			OldValue: c.OldValue,
+			NewValue: c.NewValue,
+		}
+		if c.Day != nil {
+			res[i].Day = fmt.Sprintf("%04d-%02d-%02d", c.Day.Year, c.Day.Month, c.Day.Day)
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	must(enc.Encode(res))
+}
@@ -1,23 +1,37 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
 	"git.fractalqb.de/fractalqb/tiktak/internal/reports"
 )
 
 const help = `Edit commands refer to task switch events by switch ID.
 You can find switch IDs in the first column of the output of
-'tiktak -r spans -v'.
+'tiktak -r spans -v'. Switch IDs are derived from the time and
+the task of a switch. They stay valid until that switch itself
+is changed. Edits are written to the data file; use 'tiktak -undo'
+to revert them.
 
 tiktak edit commands:
 - help           : Show tiktak edit help
 - delete (del, d): delete task switch
 - move (mv)      : move task's span to new position
+- rename         : rename a task
+- move-task      : move a task with its subtasks to a new parent task
+- merge          : merge a task with its subtasks into another task
+- attr           : set or delete task attributes
+- note           : add or delete notes of switches, tasks and days
 `
 
 func edit(args []string) {
@@ -33,6 +47,16 @@ func edit(args []string) {
 		edDelete(args)
 	case "move", "mv":
 		edMove(args)
+	case "rename":
+		edRename(args)
+	case "move-task":
+		edMoveTask(args)
+	case "merge":
+		edMerge(args)
+	case "attr":
+		edAttr(args)
+	case "note":
+		edNote(args)
 	default:
 		log.Fatalf("invalid edit command '%s'", args[0])
 	}
@@ -48,7 +72,7 @@ func edDelete(args []string) {
 	}
 	flags.Parse(args[1:])
 	for _, sid := range flags.Args() {
-		idx := mustRet(reports.ParseSpanID(sid))
+		idx := mustRet(switchIndex(sid))
 		if err := timeline.DelSwitch(idx); err != nil {
 			log.Fatalf("switch ID '%s': %s", sid, err)
 		}
@@ -68,13 +92,13 @@ func edMove(args []string) {
 		flags.Usage()
 		log.Fatal("invalid arguments")
 	}
-	sIdx := mustRet(reports.ParseSpanID(args[1]))
-	if sIdx < 0 || sIdx >= len(timeline) {
-		log.Fatalf("source switch %d (%s) out of 0..%d range", sIdx, args[1], len(timeline))
+	sIdx, err := switchIndex(args[1])
+	if err != nil {
+		log.Fatalf("source switch: %s", err)
 	}
-	dIdx := mustRet(reports.ParseSpanID(args[2]))
-	if dIdx < 0 || dIdx >= len(timeline) {
-		log.Fatalf("destination switch %d (%s) out of 0..%d range", dIdx, args[2], len(timeline))
+	dIdx, err := switchIndex(args[2])
+	if err != nil {
+		log.Fatalf("destination switch: %s", err)
 	}
 	if sIdx == dIdx {
 		return
@@ -102,3 +126,264 @@ func edMove(args []string) {
 		timeline.Insert(tt, sSw.Task(), 0, nil, dur, tiktak.AllSwitch)
 	}
 }
+
+func edRename(args []string) {
+	flags := flag.NewFlagSet("rename", flag.ExitOnError)
+	flags.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s rename: [-all] <task> <new-name>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	all := flags.Bool("all", false, "Rename task in all monthly data files")
+	flags.Parse(args[1:])
+	if flags.NArg() != 2 {
+		flags.Usage()
+		log.Fatal("invalid arguments")
+	}
+	tPath, name := flags.Arg(0), flags.Arg(1)
+	must(cmd.CheckTaskName(name))
+	editTasks(*all, func(root *tiktak.Task) error {
+		t, err := findTask(root, tPath)
+		if err != nil {
+			return err
+		}
+		return t.Rename(name)
+	})
+}
+
+func edMoveTask(args []string) {
+	flags := flag.NewFlagSet("move-task", flag.ExitOnError)
+	flags.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s move-task: [-all] <task> <new-parent>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	all := flags.Bool("all", false, "Move task in all monthly data files")
+	flags.Parse(args[1:])
+	if flags.NArg() != 2 {
+		flags.Usage()
+		log.Fatal("invalid arguments")
+	}
+	tPath, pPath := flags.Arg(0), flags.Arg(1)
+	must(cmd.CheckPathString(pPath))
+	editTasks(*all, func(root *tiktak.Task) error {
+		t, err := findTask(root, tPath)
+		if err != nil {
+			return err
+		}
+		p, err := root.GetString(pPath)
+		if err != nil {
+			return err
+		}
+		return t.MoveTo(p)
+	})
+}
+
+func edMerge(args []string) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	flags.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s merge: [-all] <task> <into-task>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	all := flags.Bool("all", false, "Merge task in all monthly data files")
+	flags.Parse(args[1:])
+	if flags.NArg() != 2 {
+		flags.Usage()
+		log.Fatal("invalid arguments")
+	}
+	tPath, dPath := flags.Arg(0), flags.Arg(1)
+	must(cmd.CheckPathString(dPath))
+	editTasks(*all, func(root *tiktak.Task) error {
+		t, err := findTask(root, tPath)
+		if err != nil {
+			return err
+		}
+		d, err := root.GetString(dPath)
+		if err != nil {
+			return err
+		}
+		return t.MergeInto(d)
+	})
+}
+
+func edAttr(args []string) {
+	flags := flag.NewFlagSet("attr", flag.ExitOnError)
+	flags.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s attr: <task> <key>=<value>|<key>=...\n", os.Args[0])
+		fmt.Fprintf(w, "An empty value deletes the attribute. Well known keys: %s\n",
+			strings.Join([]string{
+				tiktak.AttrBillable,
+				tiktak.AttrRate,
+				tiktak.AttrBudget,
+				tiktak.AttrClosed,
+				tiktak.AttrColor,
+			}, ", "),
+		)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+	if flags.NArg() < 2 {
+		flags.Usage()
+		log.Fatal("invalid arguments")
+	}
+	t := mustRet(findTask(&rootTask, flags.Arg(0)))
+	for _, kv := range flags.Args()[1:] {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			log.Fatalf("attribute '%s' without value", kv)
+		}
+		if v == "" {
+			t.DelAttr(k)
+		} else {
+			must(t.SetAttr(k, v))
+		}
+	}
+}
+
+func edNote(args []string) {
+	flags := flag.NewFlagSet("note", flag.ExitOnError)
+	flags.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s note: [-d index] <target> [text…]\n", os.Args[0])
+		fmt.Fprintln(w, `Target is a switch ID, an absolute task path, a day yyyy-mm-dd or
+'today' for the day of the current time.`)
+		flags.PrintDefaults()
+	}
+	del := flags.Int("d", 0, "Delete the note with 1-based index, negative counts from end")
+	flags.Parse(args[1:])
+	if flags.NArg() < 1 || (*del == 0) == (flags.NArg() == 1) {
+		flags.Usage()
+		log.Fatal("invalid arguments")
+	}
+	target := flags.Arg(0)
+	text := strings.Join(flags.Args()[1:], " ")
+	idx := *del
+	if idx > 0 {
+		idx--
+	}
+	checkIdx := func(notes []tiktak.Note) {
+		if i := *del; i > len(notes) || -i > len(notes) {
+			log.Fatalf("no note %d of %s", i, target)
+		}
+	}
+	if *del == 0 {
+		must(tiktak.CheckNoteText(text))
+	}
+	day, dayErr := time.Parse("2006-01-02", target)
+	switch {
+	case path.IsAbs(target):
+		t := mustRet(findTask(&rootTask, target))
+		if *del == 0 {
+			t.AddNote(text)
+		} else {
+			checkIdx(t.Notes())
+			t.DelNote(idx)
+		}
+	case target == "today" || dayErr == nil:
+		d := tiktak.DateOf(day)
+		if target == "today" {
+			d = tiktak.DateIn(now, home)
+		}
+		if *del == 0 {
+			rootTask.AddDayNote(d, text)
+		} else {
+			checkIdx(rootTask.DayNotes(d))
+			rootTask.DelDayNote(d, idx)
+		}
+	default:
+		sw := timeline[mustRet(switchIndex(target))]
+		if *del == 0 {
+			sw.AddNote(text)
+		} else {
+			checkIdx(sw.Notes())
+			sw.DelNote(idx)
+		}
+	}
+}
+
+var errNoTask = errors.New("no such task")
+
+func findTask(root *tiktak.Task, p string) (*tiktak.Task, error) {
+	if !path.IsAbs(p) {
+		return nil, fmt.Errorf("task path '%s' is not absolute", p)
+	}
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return root, nil
+	}
+	t := root.Find(false, strings.Split(p, "/")...)
+	if t == nil {
+		return nil, fmt.Errorf("%w '%s'", errNoTask, p)
+	}
+	return t, nil
+}
+
+// editTasks applies edit to the task tree of the current timeline. With all,
+// edit is also applied to all other monthly data files. Files that do not
+// know the edited task are left unchanged.
+func editTasks(all bool, edit func(root *tiktak.Task) error) {
+	err := edit(&rootTask)
+	switch {
+	case err == nil:
+		timeline = timeline.Normalize()
+		normalizeTracks(&rootTask)
+	case !all || !errors.Is(err, errNoTask):
+		log.Fatal(err)
+	}
+	if !all {
+		return
+	}
+	current, _ := filepath.Abs(file)
+	for _, df := range mustRet(cmd.DataFiles()) {
+		if abs, _ := filepath.Abs(df); abs == current {
+			continue
+		}
+		if cmd.Sealed(df) {
+			log.Println("skipped sealed", df)
+			continue
+		}
+		lock(df)
+		var root tiktak.Task
+		r := mustRet(readData(df))
+		tl, err := tiktak.Read(r, &root)
+		if err != nil {
+			log.Fatalf("%s: %s", df, err)
+		}
+		switch err := edit(&root); {
+		case errors.Is(err, errNoTask):
+			continue
+		case err != nil:
+			log.Fatalf("%s: %s", df, err)
+		}
+		normalizeTracks(&root)
+		writeFile(df, &root, tl.Normalize())
+		log.Println("updated", df)
+	}
+}
+
+// normalizeTracks normalizes the time lines of all tracks of root
+func normalizeTracks(root *tiktak.Task) {
+	for _, n := range root.Tracks() {
+		tl := root.Track(n)
+		*tl = tl.Normalize()
+	}
+}
+
+// switchIndex returns the timeline index of the switch with ID sid. For
+// backwards compatibility, sid may also be a span index as formerly written by
+// the spans report.
+func switchIndex(sid string) (int, error) {
+	if strings.IndexByte(sid, '-') >= 0 {
+		return timeline.FindID(sid)
+	}
+	idx, err := reports.ParseSpanID(sid)
+	if err != nil {
+		return -1, err
+	}
+	if idx < 0 || idx >= len(timeline) {
+		return -1, fmt.Errorf("switch %d (%s) out of 0..%d range", idx, sid, len(timeline))
+	}
+	return idx, nil
+}
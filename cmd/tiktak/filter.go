@@ -9,11 +9,13 @@ import (
 	"time"
 
 	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
 )
 
 func runFilters(ls []string) {
 	var buf bytes.Buffer
-	must(tiktak.Write(&buf, timeline))
+	must(tiktak.WriteTree(&buf, &rootTask, timeline))
+	env := append(os.Environ(), cmd.LockedEnv(locks))
 	for _, name := range ls {
 		fcmd := cfg.TikTak.Filters[name]
 		if len(fcmd) == 0 {
@@ -30,6 +32,7 @@ func runFilters(ls []string) {
 		}
 		var close bool
 		cmd := exec.Command(fcmd[0], args...)
+		cmd.Env = env
 		cmd.Stdin = &buf
 		cmd.Stderr, close = filterErr(cfg.TikTak.FilterErr)
 		if cw, ok := cmd.Stderr.(io.Closer); ok && close {
@@ -39,6 +42,7 @@ func runFilters(ls []string) {
 		buf.Reset()
 		buf.Write(data)
 	}
+	rootTask = tiktak.Task{}
 	timeline = mustRet(tiktak.Read(&buf, &rootTask))
 }
 
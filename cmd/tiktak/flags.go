@@ -30,11 +30,67 @@ If time data has to be written it is written to stderr.`)
 		"Stop timing",
 	)
 	fRept := flag.String("r", "",
-		`Select report: plain, spans, sums, sheet
+		`Select report: plain, spans, sums, sheet, periods, lint
+The periods report sums up tasks with one column per period, see
+-period. The lint report lists all warnings and fails if a warning
+has a severity above .Report.LintThreshold. Warning symbols are
+described in .Warnings.
 Config path: .Report.Default`,
+	)
+	flag.StringVar(&cfg.TikTak.Report.Periods, "period", cfg.TikTak.Report.Periods,
+		cmd.PeriodsDoc+`
+Default is month.
+Config path: .Report.Periods`,
+	)
+	flag.StringVar(&cfg.TikTak.Report.Sums, "sums", cfg.TikTak.Report.Sums,
+		`Select the columns of the sums report as comma separated periods,
+e.g. day,2w:2024-01-01,quarter. Each column sums up the period of the
+current time, see -period. Default is day,week,month.
+Config path: .Report.Sums`,
+	)
+	fFrom := flag.String("from", "",
+		`Start reports at the given time or at the start of the given year
+(yyyy), month (yyyy-mm) or day (yyyy-mm-dd). Reports with -from or -to
+read all monthly data files of the range.`,
+	)
+	fTo := flag.String("to", "",
+		`End reports at the given time or at the end of the given year
+(yyyy), month (yyyy-mm) or day (yyyy-mm-dd).`,
 	)
 	fEdit := flag.Bool("e", false,
-		"Edit timeline",
+		`Edit timeline. The edited data file is written back, use -undo to revert
+the change. Run '-e help' for the edit commands.`,
+	)
+	fSeal := flag.String("seal", "",
+		`Seal the data file of a past month (yyyy-mm) with a hash chain and an
+ed25519 signature. Sealed files cannot be changed. The key is kept in
+seal.key of the data directory. Copy seal.pub to another data directory
+to verify the seals there.`,
+	)
+	fUnseal := flag.String("unseal", "",
+		`Remove the seal of a month (yyyy-mm). The arguments are logged as the
+reason in the seal log of the data directory. Later seals are resealed.`,
+	)
+	fUndo := flag.Bool("undo", false,
+		`Undo the last change of the data file that is not yet undone. The
+journal stores the changes of the time line, its tracks and tasks.
+Undo fails if these switches or tasks were changed by other means
+after the change.
+Config path for the number of changes kept: .JournalSize`,
+	)
+	fRedo := flag.Bool("redo", false,
+		"Redo the first undone change of the data file.",
+	)
+	fLocked := flag.Bool("locked", false,
+		`Run the command given as arguments while holding the lock of the
+data file. Use this to modify data files with other tools, e.g.
+tiktak -locked -- vi "$(tiktak -q file)"
+Config path for the timeout: .LockTimeout`,
+	)
+	flag.StringVar(&track, "track", track,
+		`Select a secondary track that may overlap the main time line, e.g.
+for on-call standby. Switching, stopping, editing and reports then
+work on the track. Sums and sheet report tracks separately.`,
 	)
 	flag.StringVar(&query, "q", query,
 		fmt.Sprintf(`Query infos:
@@ -43,6 +99,16 @@ Config path: .Report.Default`,
  - file/f: Print current tiktat data file name.
  - match/m [parttern…]: Show known task names from current data file
                         that match given patterns.
+ - check [repair [-drop-bad-lines]]: Report anomalies in the current
+                   data file with their line numbers. With 'repair' write
+                   the normalized file. Repair refuses to drop unreadable
+                   lines unless -drop-bad-lines is given.
+ - diff <file>: Show the changes from the current data file to <file>.
+ - journal: List the changes of the current data file that can be undone
+            or redone together with the commands that made them. With
+            -v also list the changes of the switches and tasks.
+ - holidays: List the holidays of the current year from .Calendar
+             and the file closures.txt in the data directory.
  - format: Print example of tiktak file format.`,
 			cmd.EnvTiktakData),
 	)
@@ -87,13 +153,33 @@ creates a file and +<file> appends to a file.`,
 		"Dump config to stdout and exit.",
 	)
 	flag.BoolVar(&cfg.Verbose, "v", cfg.Verbose, "Request verbose output.")
+	flag.BoolVar(&jsonOut, "json", jsonOut, "Write query results as JSON (diff).")
+	flag.BoolVar(&strictRead, "strict", strictRead,
+		`Treat warnings in data files as errors. Reports and queries skip bad
+lines, other commands fail on them.`)
 	flag.Parse()
 	if *fCfgDump {
 		yaml.NewEncoder(os.Stdout).Encode(&cfg)
 		os.Exit(0)
 	}
 
+	if *fUndo && *fRedo {
+		log.Fatal("cannot undo and redo at the same time")
+	}
 	switch {
+	case *fLocked:
+		if flag.NArg() == 0 {
+			log.Fatal("-locked needs a command")
+		}
+		mode = LockedMode
+	case *fSeal != "":
+		mode = SealMode
+	case *fUnseal != "":
+		mode = UnsealMode
+	case *fUndo:
+		mode = UndoMode
+	case *fRedo:
+		mode = RedoMode
 	case *fStop:
 		mode = StopMode
 	case query != "":
@@ -108,17 +194,51 @@ creates a file and +<file> appends to a file.`,
 		log.Fatal("cannot switch to more than one task")
 	}
 
+	if cfg.TikTak.TimeZone != "" {
+		home = mustRet(time.LoadLocation(cfg.TikTak.TimeZone))
+	}
 	now := computeNow(*fNow)
+	weeks = mustRet(cmd.ParseWeekNumbering(cfg.TikTak.WeekNumbers, cfg.TikTak.StartOfWeek))
 
-	if *fFlag == "" {
-		file = cfg.DataFile(now)
-	} else {
+	switch {
+	case *fSeal != "" || *fUnseal != "":
+		if *fFlag != "" {
+			log.Fatal("cannot seal or unseal with -f")
+		}
+		m := *fSeal + *fUnseal
+		sealMonth = mustRet(time.ParseInLocation("2006-01", m, home))
+		file = cfg.DataFile(sealMonth)
+	case *fFlag == "":
+		file = cfg.DataFile(now.In(home))
+	default:
 		file = *fFlag
 	}
 
 	if *fRept != "" {
 		cfg.TikTak.Report.Default = *fRept
 	}
+	if *fFrom != "" || *fTo != "" {
+		if *fFlag != "" {
+			log.Fatal("cannot use -from or -to with -f")
+		}
+		if mode != ReportMode {
+			log.Fatal("-from and -to only work with reports")
+		}
+		var err error
+		if *fFrom != "" {
+			if from, _, err = cmd.ParsePeriod(*fFrom, home); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if *fTo != "" {
+			if _, to, err = cmd.ParsePeriod(*fTo, home); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+			log.Fatal("-from is not before -to")
+		}
+	}
 
 	switch cfg.TikTak.Formats {
 	case "":
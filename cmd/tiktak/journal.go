@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+)
+
+// The journal of a data file records each change of the time line, its tracks
+// and its tasks as a list of reversible ops together with the command that
+// caused it. Undo reverts the last change that is not undone, redo reapplies
+// the first undone change. Making a new change drops all undone changes. The
+// journal keeps the last Config.JournalSize changes.
+//
+// Undo and redo replay the ops on the model read from the data file. They
+// fail when the switches or tasks of a change were changed by other means
+// after the change.
+
+type jrnEntry struct {
+	Time   time.Time
+	Cmd    string
+	Ops    []tiktak.Op `json:",omitempty"`
+	Undone bool        `json:",omitempty"`
+}
+
+func readJournal(file string) (jrn []jrnEntry, enc bool, err error) {
+	data, enc, err := cmd.ReadFile(cmd.JournalFile(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, enc, err
+	}
+	scn := bufio.NewScanner(bytes.NewReader(data))
+	scn.Buffer(nil, 1<<24)
+	for lno := 1; scn.Scan(); lno++ {
+		var e jrnEntry
+		if err := json.Unmarshal(scn.Bytes(), &e); err != nil {
+			return nil, enc, fmt.Errorf("%s:%d:%w", cmd.JournalFile(file), lno, err)
+		}
+		jrn = append(jrn, e)
+	}
+	return jrn, enc, scn.Err()
+}
+
+// writeJournal writes the journal of file, encrypted if enc is set
+func writeJournal(file string, jrn []jrnEntry, enc bool) error {
+	var buf bytes.Buffer
+	jenc := json.NewEncoder(&buf)
+	for _, e := range jrn {
+		if err := jenc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if enc {
+		return cmd.WriteFile(cmd.JournalFile(file), encrypt(buf.Bytes()), 0)
+	}
+	return cmd.WriteFile(cmd.JournalFile(file), buf.Bytes(), 0)
+}
+
+// journal records the change of file from the old data to the time line tl
+// with task tree root. The journal of an encrypted file is encrypted too.
+func journal(file string, old []byte, root *tiktak.Task, tl tiktak.TimeLine, enc bool) error {
+	var oldRoot tiktak.Task
+	rd := tiktak.Reader{Lenient: true}
+	oldTL, err := rd.Read(bytes.NewReader(old), &oldRoot)
+	if err != nil {
+		return err
+	}
+	ops := tiktak.Ops(&oldRoot, root, oldTL, tl)
+	if len(ops) == 0 {
+		return nil
+	}
+	jrn, jenc, err := readJournal(file)
+	if err != nil {
+		return err
+	}
+	n := len(jrn)
+	jrn = slices.DeleteFunc(jrn, func(e jrnEntry) bool { return e.Undone })
+	e := jrnEntry{
+		Time: time.Now().Round(time.Second),
+		Cmd:  strings.Join(os.Args, " "),
+		Ops:  ops,
+	}
+	if size := cfg.TikTak.JournalSize; size > 0 && len(jrn) >= size {
+		jrn = jrn[len(jrn)-size+1:]
+	}
+	if n > 0 && len(jrn) == n && !enc && !jenc {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return cmd.AppendFile(cmd.JournalFile(file), append(line, '\n'))
+	}
+	return writeJournal(file, append(jrn, e), enc)
+}
+
+func undo(file string, redo bool) {
+	jrn, _, err := readJournal(file)
+	must(err)
+	i := slices.IndexFunc(jrn, func(e jrnEntry) bool { return e.Undone })
+	if !redo {
+		if i < 0 {
+			i = len(jrn)
+		}
+		i--
+	}
+	if i < 0 || i >= len(jrn) {
+		if redo {
+			log.Fatal("nothing to redo")
+		}
+		log.Fatal("nothing to undo")
+	}
+	e := &jrn[i]
+	checkWritable(file)
+	if len(e.Ops) == 0 {
+		log.Fatalf("journal entry '%s' has no changes to apply", e.Cmd)
+	}
+	data, enc, err := cmd.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+	var root tiktak.Task
+	tl, err := tiktak.Read(bytes.NewReader(data), &root)
+	if err != nil {
+		log.Fatalf("%s:%s", file, err)
+	}
+	ops := e.Ops
+	if !redo {
+		ops = tiktak.Invert(ops)
+	}
+	if err := tl.Apply(&root, ops); err != nil {
+		log.Fatalf("cannot apply journal entry '%s': %s", e.Cmd, err)
+	}
+	var buf bytes.Buffer
+	must(tiktak.WriteTree(&buf, &root, tl))
+	data = buf.Bytes()
+	if enc || cfg.TikTak.Encrypt {
+		enc = true
+		must(cmd.WriteFile(file, encrypt(data), cfg.TikTak.Backups))
+	} else {
+		must(cmd.WriteFile(file, data, cfg.TikTak.Backups))
+	}
+	e.Undone = !redo
+	must(writeJournal(file, jrn, enc))
+	if redo {
+		log.Printf("redone: %s", e.Cmd)
+	} else {
+		log.Printf("undone: %s", e.Cmd)
+	}
+}
+
+func showJournal(file string) {
+	jrn, _, err := readJournal(file)
+	must(err)
+	for i, e := range jrn {
+		mark := ' '
+		if e.Undone {
+			mark = 'u'
+		}
+		fmt.Printf("%3d %c %s %3d %s\n",
+			i+1,
+			mark,
+			e.Time.Format(time.DateTime),
+			len(e.Ops),
+			e.Cmd,
+		)
+		if cfg.Verbose {
+			for _, op := range e.Ops {
+				fmt.Println("           ", op)
+			}
+		}
+	}
+}
@@ -1,20 +1,25 @@
 package main
 
 import (
+	"bytes"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"git.fractalqb.de/fractalqb/tetrta"
 	"git.fractalqb.de/fractalqb/tiktak"
 	"git.fractalqb.de/fractalqb/tiktak/cmd"
 	"git.fractalqb.de/fractalqb/tiktak/internal/reports"
+	"git.fractalqb.de/fractalqb/yacfg/yasec"
 )
 
 type Config struct {
@@ -23,11 +28,53 @@ type Config struct {
 	Report   struct {
 		Default string
 		Layout  string
+		// LintThreshold: The lint report fails on warnings with a
+		// severity above the threshold.
+		LintThreshold reports.Severity
+		// Periods are the columns of the periods report, see -period.
+		Periods string
+		// Sums are the columns of the sums report, see -sums.
+		Sums string
 	}
 	StartOfWeek time.Weekday
-	Filters     map[string][]string
-	Filter      []string
-	FilterErr   string
+	// WeekNumbers is the scheme to number weeks that start on StartOfWeek:
+	// iso, us or the minimum number of days of week 1 in the new year.
+	WeekNumbers string
+	Calendar    struct {
+		// Workdays are the weekly workdays, 0 is Sunday. Default is Monday
+		// to Friday.
+		Workdays []time.Weekday
+		// Region selects public holidays by ISO 3166-2 code, e.g. DE-BY.
+		Region string
+		// Holidays are additional holidays "mm-dd name" on the same day
+		// every year.
+		Holidays []string
+	}
+	// TimeZone is the IANA name of the home time zone. Days, weeks and months
+	// are computed in this zone. Empty means local time.
+	TimeZone string
+	// Warnings maps warning symbols to their description and severity.
+	Warnings map[string]reports.WarnSym
+	// Backups is the number of rotated backups kept when a data file is
+	// rewritten. Appending to a data file does not make backups.
+	Backups int
+	// JournalSize is the maximum number of changes kept in the journal of a
+	// data file for undo and redo. Older changes are dropped. Zero means no
+	// limit. Default is 100.
+	JournalSize int
+	// LockTimeout is the maximum time to wait for another tiktak process
+	// that modifies the same data file. Default is 5s.
+	LockTimeout string
+	// Encrypt data files and their journals when they are written. The
+	// passphrase is taken from the environment, see -q format, or from Key.
+	// Encrypted files are always written encrypted.
+	Encrypt bool
+	// Key is the passphrase of encrypted data files as yasec secret. The
+	// yasec passphrase is read from the terminal when it is needed.
+	Key       *yasec.Secret
+	Filters   map[string][]string
+	Filter    []string
+	FilterErr string
 }
 
 type cmdMode int
@@ -38,6 +85,11 @@ const (
 	EditMode
 	QueryMode
 	SwitchMode
+	UndoMode
+	RedoMode
+	LockedMode
+	SealMode
+	UnsealMode
 )
 
 var (
@@ -48,17 +100,25 @@ var (
 	}{
 		TikTak: Config{
 			StartOfWeek: time.Monday, // Corresponds to ISO weeks
+			JournalSize: 100,
 		},
 	}
 
-	mode        = ReportMode
-	file, query string
-	formats                        = reports.MinutesFmts
-	tableWr     tetrta.TableWriter = &tetrta.Terminal{CellPad: "  "}
+	mode               = ReportMode
+	file, query, track string
+	jsonOut            bool
+	strictRead         bool
+	formats                               = reports.MinutesFmts
+	tableWr            tetrta.TableWriter = &tetrta.Terminal{CellPad: "  "}
 
-	now      time.Time
-	rootTask tiktak.Task
-	timeline tiktak.TimeLine
+	now       time.Time
+	home      = time.Local
+	weeks     = tiktak.ISOWeeks
+	from, to  time.Time // Report range, zero is unbounded
+	sealMonth time.Time
+	rootTask  tiktak.Task
+	timeline  tiktak.TimeLine
+	locks     []*cmd.Lock
 
 	//go:embed format.txt
 	formatMsg string
@@ -67,19 +127,25 @@ var (
 func main() {
 	must(cmd.ReadConfig(&cfg))
 	flags()
+	if cfg.TikTak.Key != nil {
+		cmd.KeySource = cmd.YasecKeySource(cfg.TikTak.Key)
+	}
 
 	switch mode {
 	case ReportMode:
-		read()
+		load()
 		showReport()
 	case StopMode:
+		lock(file)
 		read()
-		sum := reports.NewTaskSums(now, cfg.TikTak.StartOfWeek)
-		sum.Of(timeline, nil, formats)
-		timeline.Switch(now, nil)
+		tl := trackLine()
+		sum := reports.NewTaskSums(now, cfg.TikTak.StartOfWeek, home)
+		sum.Of(*tl, nil, formats)
+		tl.Switch(now, nil)
 		write(file)
-		log.Printf("Zzz\t%s\n", sumString(sum))
+		log.Printf("%sZzz\t%s\n", trackPrefix(), sumString(sum))
 	case SwitchMode:
+		lock(file)
 		read()
 		p := flag.Arg(0)
 		must(cmd.CheckPathString(p))
@@ -101,17 +167,66 @@ func main() {
 		} else {
 			t = m[0]
 		}
-		sum := reports.NewTaskSums(now, cfg.TikTak.StartOfWeek)
-		sum.Of(timeline, t, formats)
-		timeline.Switch(now, t)
+		if t.Closed() {
+			log.Printf("switching to closed task %s", t)
+		}
+		tl := trackLine()
+		sum := reports.NewTaskSums(now, cfg.TikTak.StartOfWeek, home)
+		sum.Of(*tl, t, formats)
+		tl.Switch(now, t)
 		write(file)
-		log.Printf("%s\t%s\n", t, sumString(sum))
+		log.Printf("%s%s\t%s\n", trackPrefix(), t, sumString(sum))
 	case EditMode:
+		lock(file)
 		read()
-		edit(flag.Args())
-		showReport() // TODO switch to write
+		onTrack(func() { edit(flag.Args()) })
+		write(file)
 	case QueryMode:
 		showInfos()
+	case UndoMode, RedoMode:
+		if file == "-" {
+			log.Fatal("cannot undo on stdin")
+		}
+		lock(file)
+		undo(file, mode == RedoMode)
+	case LockedMode:
+		lock(file)
+		runLocked(flag.Args())
+	case SealMode:
+		lock(file)
+		seal()
+	case UnsealMode:
+		lock(file)
+		unseal(strings.Join(flag.Args(), " "))
+	}
+	for _, l := range locks {
+		l.Release()
+	}
+}
+
+// lock acquires the lock of a data file for a read-modify-write cycle. The
+// lock is held until tiktak exits.
+func lock(file string) {
+	if file == "-" {
+		return
+	}
+	timeout := 5 * time.Second
+	if cfg.TikTak.LockTimeout != "" {
+		timeout = mustRet(time.ParseDuration(cfg.TikTak.LockTimeout))
+	}
+	locks = append(locks, mustRet(cmd.Acquire(file, timeout)))
+}
+
+func runLocked(args []string) {
+	c := exec.Command(args[0], args[1:]...)
+	c.Env = append(os.Environ(), cmd.LockedEnv(locks))
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		var xerr *exec.ExitError
+		if errors.As(err, &xerr) {
+			os.Exit(xerr.ExitCode())
+		}
+		log.Fatal(err)
 	}
 }
 
@@ -126,35 +241,158 @@ func mustRet[T any](v T, err error) T {
 	return v
 }
 
+// trackLine returns the time line of the track selected with -track or the
+// main time line if no track is selected.
+func trackLine() *tiktak.TimeLine {
+	if track == "" {
+		return &timeline
+	}
+	return mustRet(rootTask.MakeTrack(track))
+}
+
+func trackPrefix() string {
+	if track == "" {
+		return ""
+	}
+	return "@" + track + " "
+}
+
+// onTrack runs f with timeline set to the time line of the selected track.
+func onTrack(f func()) {
+	if track == "" {
+		f()
+		return
+	}
+	tl := trackLine()
+	timeline, *tl = *tl, timeline
+	defer func() { timeline, *tl = *tl, timeline }()
+	f()
+}
+
 func write(file string) {
 	runFilters(cfg.TikTak.Filter)
 	if file == "-" {
-		must(tiktak.Write(os.Stdout, timeline))
+		must(tiktak.WriteTree(os.Stdout, &rootTask, timeline))
+		return
+	}
+	writeFile(file, &rootTask, timeline)
+	if carried != nil {
+		writeFile(carried.file, &carried.root, carried.tl)
+		for _, t := range carried.carry {
+			log.Printf("%s carried over from %s", t, carried.file)
+		}
+		carried = nil
+	}
+}
+
+func writeFile(file string, root *tiktak.Task, tl tiktak.TimeLine) {
+	checkWritable(file)
+	var buf bytes.Buffer
+	must(tiktak.WriteTree(&buf, root, tl))
+	old, enc, err := cmd.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+	enc = enc || cfg.TikTak.Encrypt
+	data := buf.Bytes()
+	switch {
+	case bytes.Equal(old, data):
 		return
+	case enc:
+		must(cmd.WriteFile(file, encrypt(data), cfg.TikTak.Backups))
+	case len(old) > 0 && bytes.HasPrefix(data, old):
+		// Appending a switch at the end does not need to rewrite the file
+		must(cmd.AppendFile(file, data[len(old):]))
+	default:
+		must(cmd.WriteFile(file, data, cfg.TikTak.Backups))
 	}
-	tmp := file + "~"
-	w := mustRet(os.Create(tmp))
-	if err := tiktak.Write(w, timeline); err != nil {
-		w.Close()
-		must(err)
+	must(journal(file, old, root, tl, enc))
+}
+
+// checkWritable refuses to change sealed or archived data files
+func checkWritable(file string) {
+	checkUnsealed(file)
+	if cmd.Archived(file) {
+		log.Fatalf("%s is archived and cannot be changed", file)
 	}
-	must(w.Close())
-	must(os.Rename(tmp, file))
 }
 
+func encrypt(data []byte) []byte {
+	return mustRet(cmd.Encrypt(data, mustRet(cmd.Passphrase())))
+}
+
+// readData reads a data file that may be encrypted.
+func readData(file string) (io.Reader, error) {
+	data, _, err := cmd.ReadFile(file)
+	return bytes.NewReader(data), err
+}
+
+// read reads the data file. Reports and queries read leniently to not fail on
+// bad lines. Commands that write the file fail on errors to not lose data.
 func read() {
+	rd := tiktak.Reader{
+		Lenient: mode == ReportMode || mode == QueryMode,
+		Strict:  strictRead,
+	}
+	defer func() {
+		for _, d := range rd.Diagnostics {
+			log.Printf("%s:%s", file, d)
+		}
+	}()
 	if file == "-" {
-		timeline = mustRet(tiktak.Read(os.Stdin, &rootTask))
+		timeline = mustRet(rd.Read(os.Stdin, &rootTask))
 		return
 	}
-	if _, err := os.Stat(file); os.IsNotExist(err) {
-		if !copyTemplate() {
+	if !cmd.Exists(file) {
+		tmpl := copyTemplate()
+		if file == cfg.DataFile(now.In(home)) {
+			defer carryOver()
+		}
+		if !tmpl {
 			return
 		}
 	}
-	r := mustRet(os.Open(file))
-	defer r.Close()
-	timeline = mustRet(tiktak.Read(r, &rootTask))
+	r := mustRet(readData(file))
+	var err error
+	if timeline, err = rd.Read(r, &rootTask); err != nil {
+		log.Fatalf("%s:%s", file, err)
+	}
+	if _, err := cmd.VerifySeal(file, trustedKey()); err != nil {
+		if !rd.Lenient && !errors.Is(err, cmd.ErrSealUnverified) {
+			log.Fatal(err)
+		}
+		log.Print(err)
+	}
+}
+
+// load reads the data of reports. With -from or -to it loads the range from
+// all monthly data files. The sums report of the default data file also loads
+// the part of the current week that is in the previous month.
+func load() {
+	switch {
+	case !from.IsZero() || !to.IsZero():
+	case file == cfg.DataFile(now.In(home)) && cfg.TikTak.Report.Default == "sums":
+		sow := tiktak.StartDay(tiktak.LastDay(cfg.TikTak.StartOfWeek, now, home), 0, home)
+		if !sow.Before(tiktak.StartMonth(now, 0, home)) {
+			read()
+			return
+		}
+		from = sow
+	default:
+		read()
+		return
+	}
+	store := cmd.NewStore(home)
+	store.Lenient, store.Strict = true, strictRead
+	store.SealKey = trustedKey()
+	store.Diagnostic = func(file string, d *tiktak.ReadError) {
+		log.Printf("%s:%s", file, d)
+	}
+	until := to
+	if until.IsZero() || until.After(now) {
+		until = time.Time{}
+	}
+	timeline = mustRet(store.Load(&rootTask, from, until))
 }
 
 func copyTemplate() bool {
@@ -162,11 +400,7 @@ func copyTemplate() bool {
 	if _, err := os.Stat(tmplFile); os.IsNotExist(err) {
 		return false
 	}
-	r := mustRet(os.Open(tmplFile))
-	defer r.Close()
-	w := mustRet(os.Create(file))
-	defer w.Close()
-	mustRet(io.Copy(w, r))
+	must(cmd.WriteFile(file, mustRet(os.ReadFile(tmplFile)), 0))
 	return true
 }
 
@@ -192,22 +426,91 @@ func showReport() {
 	runFilters(cfg.TikTak.Filter)
 	switch cfg.TikTak.Report.Default {
 	case "", "plain":
-		tiktak.Write(os.Stdout, timeline)
+		tiktak.WriteTree(os.Stdout, &rootTask, timeline)
 	case "spans":
-		r := reports.Spans{Report: reptCfg(), Verbose: cfg.Verbose}
-		r.Write(os.Stdout, timeline, now)
+		r := reports.Spans{Report: reptCfg(), Root: &rootTask, Verbose: cfg.Verbose}
+		r.Write(os.Stdout, *trackLine(), now)
 	case "sums":
-		r := reports.Sums{Report: reptCfg(), WeekStart: cfg.TikTak.StartOfWeek}
-		r.Write(os.Stdout, timeline, now)
+		r := reports.Sums{
+			Report: reptCfg(),
+			Track:  track,
+		}
+		if spec := cfg.TikTak.Report.Sums; spec != "" {
+			for _, s := range strings.Split(spec, ",") {
+				ps := mustRet(cmd.ParsePeriods(strings.TrimSpace(s), weeks, home))
+				r.Columns = append(r.Columns, ps)
+			}
+		}
+		r.Write(os.Stdout, *trackLine(), now)
+		if track == "" {
+			for _, n := range rootTask.Tracks() {
+				fmt.Println()
+				r.Track = n
+				r.Write(os.Stdout, *rootTask.Track(n), now)
+			}
+		}
 	case "sheet":
-		r := reports.Sheet{Report: reptCfg(), WeekStart: cfg.TikTak.StartOfWeek}
+		r := reports.Sheet{
+			Report:  reptCfg(),
+			Root:    &rootTask,
+			Verbose: cfg.Verbose,
+		}
+		if calendarConfigured() {
+			r.Calendar = calendar()
+		}
 		for _, arg := range flag.Args() {
 			ts := match(&rootTask, arg)
 			r.Tasks = append(r.Tasks, ts...)
 		}
-		r.Write(os.Stdout, timeline, now)
+		if track == "" {
+			r.Tracks = rootTask.Tracks()
+		}
+		r.Write(os.Stdout, *trackLine(), now)
+	case "periods":
+		spec := cfg.TikTak.Report.Periods
+		if spec == "" {
+			spec = "month"
+		}
+		r := reports.PeriodSums{
+			Report:  reptCfg(),
+			Periods: mustRet(cmd.ParsePeriods(spec, weeks, home)),
+			Track:   track,
+		}
+		r.Write(os.Stdout, *trackLine(), now)
+		if track == "" {
+			for _, n := range rootTask.Tracks() {
+				fmt.Println()
+				r.Track = n
+				r.Write(os.Stdout, *rootTask.Track(n), now)
+			}
+		}
+	case "lint":
+		r := reports.Lint{
+			Report:    reptCfg(),
+			Symbols:   make(map[rune]reports.WarnSym),
+			Threshold: cfg.TikTak.Report.LintThreshold,
+		}
+		for s, ws := range cfg.TikTak.Warnings {
+			sym, sz := utf8.DecodeRuneInString(s)
+			if sz == 0 || sz != len(s) {
+				log.Fatalf("warning symbol '%s' is not a single character", s)
+			}
+			r.Symbols[sym] = ws
+		}
+		r.Track = track
+		n := r.Write(os.Stdout, *trackLine(), now)
+		if track == "" {
+			for _, t := range rootTask.Tracks() {
+				r.Track = t
+				n += r.Write(os.Stdout, *rootTask.Track(t), now)
+			}
+		}
+		if n > 0 {
+			log.Printf("%d warnings above %s", n, r.Threshold)
+			os.Exit(1)
+		}
 	default:
-		log.Fatalf("unknown report '%s'", cfg.TikTak.Report)
+		log.Fatalf("unknown report '%s'", cfg.TikTak.Report.Default)
 	}
 }
 
@@ -222,7 +525,7 @@ func showInfos() {
 		var tbl tetrta.Table
 		if cfg.Verbose {
 			crsr := tbl.At(0, 0).With(reports.Bold()).
-				SetStrings("Match", "Task", "Title").
+				SetStrings("Match", "Task", "Title", "Attributes").
 				NextRow()
 			for _, arg := range flag.Args() {
 				matches := match(&rootTask, arg)
@@ -230,7 +533,7 @@ func showInfos() {
 					crsr.SetStrings(arg, "-").NextRow()
 				} else {
 					for _, m := range matches {
-						crsr.SetStrings(arg, m.String(), m.Title()).NextRow()
+						crsr.SetStrings(arg, m.String(), m.Title(), attrString(m)).NextRow()
 					}
 				}
 			}
@@ -247,6 +550,14 @@ func showInfos() {
 				}
 			}
 		}
+	case "check":
+		check(flag.Args())
+	case "journal":
+		showJournal(file)
+	case "diff":
+		diff(flag.Args())
+	case "holidays":
+		showHolidays()
 	case "format":
 		fmt.Print(formatMsg)
 	default:
@@ -254,4 +565,18 @@ func showInfos() {
 	}
 }
 
-func reptCfg() reports.Report { return reports.Report{Layout: tableWr, Fmts: formats} }
+func attrString(t *tiktak.Task) string {
+	var sb strings.Builder
+	for i, k := range t.AttrKeys() {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		v, _ := t.Attr(k)
+		fmt.Fprintf(&sb, "%s=%s", k, v)
+	}
+	return sb.String()
+}
+
+func reptCfg() reports.Report {
+	return reports.Report{Layout: tableWr, Fmts: formats, Location: home, Weeks: weeks}
+}
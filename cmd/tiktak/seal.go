@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"log"
+	"os"
+
+	"git.fractalqb.de/fractalqb/tiktak"
+	"git.fractalqb.de/fractalqb/tiktak/cmd"
+)
+
+func seal() {
+	if end := tiktak.StartMonth(sealMonth, 1, home); now.Before(end) {
+		log.Fatalf("cannot seal %s before it has ended", sealMonth.Format("2006-01"))
+	}
+	if !cmd.Exists(file) {
+		log.Fatalf("no data file %s", file)
+	}
+	read()
+	if l := len(timeline); l > 0 && timeline[l-1].Task() != nil {
+		log.Fatalf("cannot seal %s with running task %s", file, timeline[l-1].Task())
+	}
+	for _, n := range rootTask.Tracks() {
+		if tl := *rootTask.Track(n); len(tl) > 0 && tl[len(tl)-1].Task() != nil {
+			log.Fatalf("cannot seal %s with running task @%s %s", file, n, tl[len(tl)-1].Task())
+		}
+	}
+	must(cmd.Seal(file, mustRet(cmd.SealKey(true))))
+	log.Printf("sealed %s", file)
+}
+
+func unseal(reason string) {
+	if !cmd.Sealed(file) {
+		log.Fatalf("%s is not sealed", file)
+	}
+	if reason == "" {
+		reason = "-"
+	}
+	key, err := cmd.SealKey(false)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+	must(cmd.Unseal(file, reason, key))
+	log.Printf("unsealed %s", file)
+}
+
+// checkUnsealed refuses to change sealed data files
+func checkUnsealed(file string) {
+	if cmd.Sealed(file) {
+		log.Fatalf("%s is sealed, remove the seal with -unseal first", file)
+	}
+}
+
+// trustedKey returns the public key to verify seals, if any
+func trustedKey() ed25519.PublicKey {
+	key, err := cmd.TrustedSealKey()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		log.Fatal(err)
+	}
+	return key
+}
@@ -5,7 +5,10 @@ import (
 	"log"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"git.fractalqb.de/fractalqb/tiktak"
 )
 
 var relTimeRegexp = regexp.MustCompile(`^([ymwdHM])-(\d+)(?:T(\d\d:\d\d))?$`)
@@ -87,6 +90,90 @@ func ParseTime(tstr string) (time.Time, error) {
 	), nil
 }
 
+// ParsePeriod parses a year yyyy, a month yyyy-mm or a day yyyy-mm-dd in loc
+// and returns its start and end. Other formats are parsed with [ParseTime]
+// and result in start and end being the same time.
+func ParsePeriod(s string, loc *time.Location) (start, end time.Time, err error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", s, loc); err == nil {
+		return t, t.AddDate(0, 1, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006", s, loc); err == nil {
+		return t, t.AddDate(1, 0, 0), nil
+	}
+	if start, err = ParseTime(s); err != nil {
+		return start, start, err
+	}
+	return start, start, nil
+}
+
+var cycleRegexp = regexp.MustCompile(`^(\d+)w:(\d{4}-\d\d-\d\d)$`)
+
+// PeriodsDoc describes the period specs of [ParsePeriods].
+const PeriodsDoc = `Periods:
+ - day, week, month, quarter, year
+ - year:m          : Fiscal year that starts with month m (1–12).
+ - Nw:yyyy-mm-dd   : Cycles of N weeks, one starts on the given day.`
+
+// ParsePeriods parses the periods spec s, see [PeriodsDoc]. Weeks are
+// numbered with weeks. Periods are computed in loc.
+func ParsePeriods(s string, weeks tiktak.WeekNumbering, loc *time.Location) (tiktak.Periods, error) {
+	switch s {
+	case "day":
+		return tiktak.DayBuckets{Location: loc}, nil
+	case "week":
+		return tiktak.WeekBuckets{Start: weeks.Start, MinDays: weeks.MinDays, Location: loc}, nil
+	case "month":
+		return tiktak.MonthBuckets{Location: loc}, nil
+	case "quarter":
+		return tiktak.QuarterBuckets{Location: loc}, nil
+	case "year":
+		return tiktak.YearBuckets{Location: loc}, nil
+	}
+	if m, ok := strings.CutPrefix(s, "year:"); ok {
+		n, err := strconv.Atoi(m)
+		if err != nil || n < 1 || n > 12 {
+			return nil, fmt.Errorf("invalid start month of fiscal year: '%s'", m)
+		}
+		return tiktak.YearBuckets{Start: time.Month(n), Location: loc}, nil
+	}
+	if match := cycleRegexp.FindStringSubmatch(s); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid number of weeks in '%s'", s)
+		}
+		anchor, err := time.ParseInLocation("2006-01-02", match[2], loc)
+		if err != nil {
+			return nil, err
+		}
+		return tiktak.CycleBuckets{Weeks: n, Anchor: anchor, Location: loc}, nil
+	}
+	return nil, fmt.Errorf("invalid periods: '%s'", s)
+}
+
+// ParseWeekNumbering parses the week numbering scheme s for weeks that start
+// on sow. With "iso" or "" week 1 is the first week with at least 4 days in
+// the new year, with "us" it is the week that contains January 1st. A number
+// 1–7 is the minimum number of days of week 1 in the new year.
+func ParseWeekNumbering(s string, sow time.Weekday) (tiktak.WeekNumbering, error) {
+	res := tiktak.WeekNumbering{Start: sow}
+	switch s {
+	case "", "iso":
+		res.MinDays = tiktak.ISOWeeks.MinDays
+	case "us":
+		res.MinDays = tiktak.USWeeks.MinDays
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 7 {
+			return res, fmt.Errorf("invalid week numbers: '%s'", s)
+		}
+		res.MinDays = n
+	}
+	return res, nil
+}
+
 var durRegexp = regexp.MustCompile(`^(\d+)([smh]?)$`)
 
 func ParseDuration(s string) (time.Duration, error) {
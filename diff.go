@@ -0,0 +1,265 @@
+package tiktak
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ChangeKind int
+
+const (
+	// Added: Switch B is not in the old time line.
+	Added ChangeKind = iota
+	// Removed: Switch A is not in the new time line.
+	Removed
+	// Rescheduled: Switch A moved to the time of B.
+	Rescheduled
+	// Retasked: Switches A and B happen at the same time but switch to
+	// different tasks.
+	Retasked
+	// NotesChanged: Switches A and B, task Task or day Day have different
+	// notes.
+	NotesChanged
+	// TitleChanged: Task Task has a different title.
+	TitleChanged
+	// AttrChanged: Attribute Attr of task Task has a different value.
+	AttrChanged
+)
+
+var changeKindNames = []string{
+	"added",
+	"removed",
+	"rescheduled",
+	"retasked",
+	"notes changed",
+	"title changed",
+	"attribute changed",
+}
+
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindNames) {
+		return fmt.Sprintf("change(%d)", int(k))
+	}
+	return changeKindNames[k]
+}
+
+func (k ChangeKind) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+// Change is a single difference found by [Diff]. A is from the old and B is
+// from the new time line.
+type Change struct {
+	Kind ChangeKind
+	A, B *Switch
+	// Task is the path of the task with changed title, attribute or notes.
+	Task               string
+	OldTitle, NewTitle string
+	OldNotes, NewNotes []Note
+	// Day is the day with changed day notes.
+	Day                *Date
+	Attr               string
+	OldValue, NewValue string
+}
+
+func (c Change) String() string {
+	sw := func(s *Switch) string {
+		if t := s.Task(); t != nil {
+			return s.When().Format(IOTimeFmt) + " " + t.String()
+		}
+		return s.When().Format(IOTimeFmt) + " stop"
+	}
+	switch c.Kind {
+	case Added:
+		return "+ " + sw(c.B)
+	case Removed:
+		return "- " + sw(c.A)
+	case Rescheduled:
+		return fmt.Sprintf("~ %s → %s", sw(c.A), c.B.When().Format(IOTimeFmt))
+	case Retasked:
+		return fmt.Sprintf("* %s → %s", sw(c.A), taskPath(c.B.Task()))
+	case NotesChanged:
+		if c.Day != nil {
+			return fmt.Sprintf("! notes of %s: %d → %d", c.Day.format(dayNoteFmt), len(c.OldNotes), len(c.NewNotes))
+		}
+		if c.Task != "" {
+			return fmt.Sprintf("! notes of %s: %d → %d", c.Task, len(c.OldNotes), len(c.NewNotes))
+		}
+		return fmt.Sprintf("! notes at %s: %d → %d", sw(c.B), len(c.OldNotes), len(c.NewNotes))
+	case TitleChanged:
+		return fmt.Sprintf("# title of %s: '%s' → '%s'", c.Task, c.OldTitle, c.NewTitle)
+	case AttrChanged:
+		return fmt.Sprintf("= %s of %s: '%s' → '%s'", c.Attr, c.Task, c.OldValue, c.NewValue)
+	}
+	return c.Kind.String()
+}
+
+func taskPath(t *Task) string {
+	if t == nil {
+		return "stop"
+	}
+	return t.String()
+}
+
+// Diff compares the old time line a with the new time line b and the task
+// tree aRoot with bRoot. The time lines may belong to different task trees;
+// tasks are compared by their paths. A nil root compares as an empty task
+// tree. Switches at the same time in a and b are matched. Of the other
+// switches, those that switch to the same task between the same matched
+// switches are considered to be rescheduled. The resulting changes are
+// ordered by time with task and day note changes at the end.
+func Diff(aRoot, bRoot *Task, a, b TimeLine) (res []Change) {
+	// seg is the number of matched switches before an unmatched one
+	type unmatched struct {
+		s   *Switch
+		seg int
+	}
+	var ua, ub []unmatched
+	i, j, seg := 0, 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].When().Before(b[j].When())):
+			ua = append(ua, unmatched{a[i], seg})
+			i++
+		case i == len(a) || b[j].When().Before(a[i].When()):
+			ub = append(ub, unmatched{b[j], seg})
+			j++
+		default:
+			sa, sb := a[i], b[j]
+			if taskPath(sa.Task()) != taskPath(sb.Task()) {
+				res = append(res, Change{Kind: Retasked, A: sa, B: sb})
+			}
+			if !slices.Equal(sa.Notes(), sb.Notes()) {
+				res = append(res, Change{
+					Kind:     NotesChanged,
+					A:        sa,
+					B:        sb,
+					OldNotes: sa.Notes(),
+					NewNotes: sb.Notes(),
+				})
+			}
+			i++
+			j++
+			seg++
+		}
+	}
+	for _, u := range ua {
+		k := slices.IndexFunc(ub, func(v unmatched) bool {
+			return v.seg == u.seg && taskPath(v.s.Task()) == taskPath(u.s.Task())
+		})
+		if k < 0 {
+			res = append(res, Change{Kind: Removed, A: u.s})
+			continue
+		}
+		res = append(res, Change{Kind: Rescheduled, A: u.s, B: ub[k].s})
+		if !slices.Equal(u.s.Notes(), ub[k].s.Notes()) {
+			res = append(res, Change{
+				Kind:     NotesChanged,
+				A:        u.s,
+				B:        ub[k].s,
+				OldNotes: u.s.Notes(),
+				NewNotes: ub[k].s.Notes(),
+			})
+		}
+		ub = slices.Delete(ub, k, k+1)
+	}
+	for _, u := range ub {
+		res = append(res, Change{Kind: Added, B: u.s})
+	}
+	slices.SortStableFunc(res, func(x, y Change) int {
+		return x.when().Compare(y.when())
+	})
+	return append(res, diffTasks(aRoot, bRoot)...)
+}
+
+func (c *Change) when() time.Time {
+	if c.A != nil {
+		return c.A.When()
+	}
+	return c.B.When()
+}
+
+func diffTasks(a, b *Task) (res []Change) {
+	var none Task
+	if a == nil {
+		a = &none
+	}
+	if b == nil {
+		b = &none
+	}
+	diffTask := func(path string, ta, tb *Task) {
+		var empty Task
+		if ta == nil {
+			ta = &empty
+		}
+		if tb == nil {
+			tb = &empty
+		}
+		if ta.Title() != tb.Title() {
+			res = append(res, Change{
+				Kind:     TitleChanged,
+				Task:     path,
+				OldTitle: ta.Title(),
+				NewTitle: tb.Title(),
+			})
+		}
+		keys := ta.AttrKeys()
+		for _, k := range tb.AttrKeys() {
+			if _, ok := ta.Attr(k); !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			va, _ := ta.Attr(k)
+			vb, _ := tb.Attr(k)
+			if va != vb {
+				res = append(res, Change{
+					Kind:     AttrChanged,
+					Task:     path,
+					Attr:     k,
+					OldValue: va,
+					NewValue: vb,
+				})
+			}
+		}
+		if !slices.Equal(ta.Notes(), tb.Notes()) {
+			res = append(res, Change{
+				Kind:     NotesChanged,
+				Task:     path,
+				OldNotes: ta.Notes(),
+				NewNotes: tb.Notes(),
+			})
+		}
+	}
+	a.Visit(true, func(ta *Task) error {
+		diffTask(ta.String(), ta, b.Find(false, ta.Path()...))
+		return nil
+	})
+	b.Visit(true, func(tb *Task) error {
+		if a.Find(false, tb.Path()...) == nil {
+			diffTask(tb.String(), nil, tb)
+		}
+		return nil
+	})
+	slices.SortStableFunc(res, func(x, y Change) int { return strings.Compare(x.Task, y.Task) })
+	days := a.NoteDays()
+	for _, d := range b.NoteDays() {
+		if _, ok := a.Root().days[d]; !ok {
+			days = append(days, d)
+		}
+	}
+	slices.SortFunc(days, func(x, y Date) int { return x.Compare(&y) })
+	for _, d := range days {
+		na, nb := a.DayNotes(d), b.DayNotes(d)
+		if !slices.Equal(na, nb) {
+			res = append(res, Change{
+				Kind:     NotesChanged,
+				Day:      &d,
+				OldNotes: na,
+				NewNotes: nb,
+			})
+		}
+	}
+	return res
+}
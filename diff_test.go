@@ -0,0 +1,78 @@
+package tiktak
+
+import (
+	"fmt"
+	"strings"
+)
+
+func ExampleDiff() {
+	var ra, rb Task
+	a, _ := Read(strings.NewReader(`/b Old title
+2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00Z /b
+2023-04-01T10:00:00Z /c
+	. Note
+2023-04-01T11:00:00Z /a
+2023-04-01T12:00:00Z`), &ra)
+	b, _ := Read(strings.NewReader(`/b New title
+2023-04-01T08:00:00Z /a
+2023-04-01T09:30:00Z /b
+2023-04-01T10:00:00Z /d
+2023-04-01T11:00:00Z /a
+	. Other note
+2023-04-01T12:00:00Z
+2023-04-01T13:00:00Z /e`), &rb)
+	for _, c := range Diff(&ra, &rb, a, b) {
+		fmt.Println(c)
+	}
+	// Output:
+	// ~ 2023-04-01T09:00:00Z /b → 2023-04-01T09:30:00Z
+	// * 2023-04-01T10:00:00Z /c → /d
+	// ! notes at 2023-04-01T10:00:00Z /d: 1 → 0
+	// ! notes at 2023-04-01T11:00:00Z /a: 0 → 1
+	// + 2023-04-01T13:00:00Z /e
+	// # title of /b: 'Old title' → 'New title'
+}
+
+func ExampleDiff_tasks() {
+	var ra, rb Task
+	a, _ := Read(strings.NewReader(`/a [rate=95] Gone
+/b [billable=true]
+	. Task note
+2023-04-01
+	. Day note
+2023-04-01T08:00:00Z /b
+2023-04-01T12:00:00Z`), &ra)
+	b, _ := Read(strings.NewReader(`/b [billable=false color=red]
+2023-04-01T08:00:00Z /b
+2023-04-01T12:00:00Z`), &rb)
+	for _, c := range Diff(&ra, &rb, a, b) {
+		fmt.Println(c)
+	}
+	// Output:
+	// # title of /a: 'Gone' → ''
+	// = rate of /a: '95' → ''
+	// = billable of /b: 'true' → 'false'
+	// = color of /b: '' → 'red'
+	// ! notes of /b: 1 → 0
+	// ! notes of 2023-04-01: 1 → 0
+}
+
+func ExampleDiff_emptyTimeLine() {
+	var ra, rb Task
+	a, _ := Read(strings.NewReader(`/a Title
+2023-04-01
+	. Day note`), &ra)
+	b, _ := Read(strings.NewReader(`/a Title
+/b [rate=95]
+2023-04-01T08:00:00Z /a
+2023-04-01T12:00:00Z`), &rb)
+	for _, c := range Diff(&ra, &rb, a, b) {
+		fmt.Println(c)
+	}
+	// Output:
+	// + 2023-04-01T08:00:00Z /a
+	// + 2023-04-01T12:00:00Z stop
+	// = rate of /b: '' → '95'
+	// ! notes of 2023-04-01: 1 → 0
+}
@@ -13,6 +13,7 @@ require (
 	git.fractalqb.de/fractalqb/gomk v0.11.17
 	git.fractalqb.de/fractalqb/tetrta v0.1.0
 	golang.org/x/mod v0.28.0
+	golang.org/x/sys v0.36.0
 	golang.org/x/time v0.13.0
 )
 
@@ -26,7 +27,6 @@ require (
 	github.com/kr/text v0.2.0 // indirect
 	github.com/rjeczalik/notify v0.9.3 // indirect
 	github.com/rogpeppe/go-internal v1.14.1 // indirect
-	golang.org/x/sys v0.36.0 // indirect
 )
 
 require (
@@ -13,6 +13,25 @@ import (
 type Report struct {
 	Layout tetrta.TableWriter
 	Fmts   Formats
+	// Location is the home time zone that is used for day, week and month
+	// boundaries and to display times. Nil means local time.
+	Location *time.Location
+	// Weeks numbers weeks and tells when they start. Zero means ISO weeks.
+	Weeks tiktak.WeekNumbering
+}
+
+func (r *Report) weeks() tiktak.WeekNumbering {
+	if r.Weeks.MinDays == 0 {
+		return tiktak.ISOWeeks
+	}
+	return r.Weeks
+}
+
+func (r *Report) loc() *time.Location {
+	if r.Location == nil {
+		return time.Local
+	}
+	return r.Location
 }
 
 type Formats interface {
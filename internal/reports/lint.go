@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+type Severity int
+
+const (
+	SevInfo Severity = iota
+	SevWarning
+	SevError
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+func ParseSeverity(s string) (Severity, error) {
+	if i := slices.Index(severityNames, strings.ToLower(s)); i >= 0 {
+		return Severity(i), nil
+	}
+	return SevInfo, fmt.Errorf("invalid severity '%s'", s)
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+func (s *Severity) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseSeverity(string(text))
+	return err
+}
+
+// WarnSym describes the warnings with a specific symbol.
+type WarnSym struct {
+	Description string
+	Severity    Severity
+}
+
+// DefaultWarnSyms are the symbols of warnings created by tiktak itself.
+var DefaultWarnSyms = map[rune]WarnSym{
+	'µ':                {Description: "Micro gap between task switches", Severity: SevInfo},
+	tiktak.ConflictSym: {Description: "Merge conflict", Severity: SevError},
+}
+
+// Lint lists all warnings of a time line grouped by their symbols.
+type Lint struct {
+	Report
+	Symbols map[rune]WarnSym
+	// Threshold: Warnings with a severity above Threshold are counted as
+	// failures by Write.
+	Threshold Severity
+	// Track is the name of the track that is written. Empty for the main
+	// time line.
+	Track string
+}
+
+func (l *Lint) symbol(sym rune) WarnSym {
+	if ws, ok := l.Symbols[sym]; ok {
+		return ws
+	}
+	if ws, ok := DefaultWarnSyms[sym]; ok {
+		return ws
+	}
+	return WarnSym{Description: "Unregistered warning", Severity: SevWarning}
+}
+
+// Write writes the lint report of tl and returns the number of warnings with
+// a severity above l.Threshold.
+func (l *Lint) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) (fails int) {
+	fmts := l.Fmts
+	if fmts == nil {
+		fmts = MinutesFmts
+	}
+	loc := l.loc()
+	type warning struct {
+		sw   *tiktak.Switch
+		text string
+	}
+	warns := make(map[rune][]warning)
+	for _, sw := range tl {
+		for _, n := range sw.Notes() {
+			if tiktak.Warning(n) {
+				warns[n.Sym] = append(warns[n.Sym], warning{sw, n.Text})
+			}
+		}
+	}
+	syms := make([]rune, 0, len(warns))
+	for sym := range warns {
+		syms = append(syms, sym)
+	}
+	slices.Sort(syms)
+
+	var tbl tetrta.Table
+	crsr := tbl.At(0, 0)
+	if l.Track != "" && len(syms) > 0 {
+		crsr.SetString("@"+l.Track, tetrta.SpanAll, Bold(), Underline()).NextRow()
+	}
+	for _, sym := range syms {
+		ws := l.symbol(sym)
+		style := Bold()
+		if ws.Severity > l.Threshold {
+			fails += len(warns[sym])
+			style = tetrta.AddStyles(style, Warn())
+		}
+		crsr.SetString(
+			fmt.Sprintf("%c %s [%s]: %d", sym, ws.Description, ws.Severity, len(warns[sym])),
+			tetrta.SpanAll,
+			style,
+		).NextRow()
+		for _, wn := range warns[sym] {
+			when := wn.sw.When().In(loc)
+			crsr.SetStrings(
+				wn.sw.ID(),
+				fmts.ShortDate(when),
+				fmts.Clock(when),
+			)
+			if t := wn.sw.Task(); t == nil {
+				crsr.SetString("-", Muted())
+			} else {
+				crsr.SetString(t.String(), TaskColor(t))
+			}
+			crsr.SetString(wn.text).NextRow()
+		}
+	}
+	l.Layout.Write(w, &tbl)
+	return fails
+}
@@ -0,0 +1,64 @@
+package reports
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func ExampleLint() {
+	tl, _ := tiktak.Read(strings.NewReader(`2023-04-01T10:00:00Z /a
+	!µ µ-Gap 30s < 1m0s
+2023-04-01T10:00:30Z /b
+	!? Forgot to switch
+2023-04-01T12:00:00Z`), nil)
+	lint := Lint{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+		},
+		Symbols: map[rune]WarnSym{'?': {Description: "Guessed", Severity: SevError}},
+	}
+	fails := lint.Write(os.Stdout, tl, time.Now())
+	fmt.Println("fails:", fails)
+	// Output:
+	// ? Guessed [error]: 1;;;;
+	// RSFL4U-TG;Sat, 01 Apr;10:00;/b;Forgot to switch
+	// µ Micro gap between task switches [info]: 1;;;;
+	// RSFL40-WD;Sat, 01 Apr;10:00;/a;µ-Gap 30s < 1m0s
+	// fails: 1
+}
+
+func ExampleLint_track() {
+	var root tiktak.Task
+	tiktak.Read(strings.NewReader(`@oncall 2023-04-01T20:00:00Z /oncall
+	!? Forgot to switch
+@oncall 2023-04-01T22:00:00Z`), &root)
+	lint := Lint{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+		},
+		Symbols: map[rune]WarnSym{'?': {Description: "Guessed", Severity: SevError}},
+		Track:   "oncall",
+	}
+	fails := lint.Write(os.Stdout, *root.Track("oncall"), time.Now())
+	fmt.Println("fails:", fails)
+	// Output:
+	// @oncall;;;;
+	// ? Guessed [error]: 1;;;;
+	// RSGCW0-0N;Sat, 01 Apr;20:00;/oncall;Forgot to switch
+	// fails: 1
+}
+
+func ExampleParseSeverity() {
+	fmt.Println(ParseSeverity("Warning"))
+	fmt.Println(ParseSeverity("fatal"))
+	// Output:
+	// warning <nil>
+	// info invalid severity 'fatal'
+}
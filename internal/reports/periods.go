@@ -0,0 +1,100 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+// PeriodSums writes the time of each task with its subtasks with one column
+// for each period of the time line, e.g. for quarterly billing or bi-weekly
+// pay periods.
+type PeriodSums struct {
+	Report
+	Periods tiktak.Periods
+	// Track is the name of the track that is written. Empty for the main
+	// time line.
+	Track string
+}
+
+func (ps *PeriodSums) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
+	troot := tl.FirstTask().Root()
+	if troot == nil || len(tl) == 0 {
+		return
+	}
+	fmts := ps.Fmts
+	if fmts == nil {
+		fmts = MinutesFmts
+	}
+	start, end := tl[0].When(), tl[len(tl)-1].When()
+	if tl[len(tl)-1].Task() != nil && now.After(end) {
+		end = now
+	}
+	var periods []tiktak.Period
+	for p := range tiktak.PeriodsIn(ps.Periods, start, end) {
+		periods = append(periods, p)
+	}
+	if len(periods) == 0 {
+		periods = append(periods, tiktak.PeriodOf(ps.Periods, start))
+	}
+	cube := tiktak.Aggregate(tl, time.Time{}, time.Time{}, now,
+		ps.Periods,
+		tiktak.TotalBucket{},
+	)
+
+	title := "PERIODS"
+	if ps.Track != "" {
+		title = "PERIODS @" + ps.Track
+	}
+	var tbl tetrta.Table
+	crsr := tbl.At(0, 0).
+		SetString(fmt.Sprintf("%s: %s – %s",
+			title,
+			periods[0].Label(),
+			periods[len(periods)-1].Label(),
+		), tetrta.SpanAll, Bold()).NextRow().
+		SetString("", tetrta.SpanAll, tetrta.CellPad('-')).NextRow().
+		With(tetrta.Left).SetStrings("", "Task")
+	for _, p := range periods {
+		crsr.SetString(p.Label(), tetrta.Left)
+	}
+	crsr.SetString("Total", tetrta.Left).NextRow().
+		SetString("", tetrta.SpanAll, tetrta.CellPad('-')).NextRow()
+
+	troot.Visit(false, func(t *tiktak.Task) error {
+		var markers string
+		style := tetrta.NoStyle()
+		if cube.Get(t, true, 1, now).Open {
+			style = Bold()
+			markers = ">"
+		}
+		if t.Closed() {
+			markers += "x"
+		}
+		crsr.SetString(markers, style).
+			SetString(t.String(), tetrta.AddStyles(style, TaskColor(t)))
+		cell := func(c tiktak.Cell) {
+			switch {
+			case c.Duration == 0:
+				crsr.SetString(empty, tetrta.Center)
+			case c.Warning:
+				crsr.SetString(fmts.Duration(c.Duration), tetrta.AddStyles(style, Warn()))
+			default:
+				crsr.SetString(fmts.Duration(c.Duration), style)
+			}
+		}
+		for _, p := range periods {
+			cell(cube.Get(t, true, 0, p.Start))
+		}
+		cell(cube.Get(t, true, 1, now))
+		crsr.NextRow()
+		return nil
+	})
+	for i := 2; i < tbl.Columns(); i++ {
+		tbl.Align(tetrta.Right, i)
+	}
+	ps.Layout.Write(w, &tbl)
+}
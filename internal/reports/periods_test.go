@@ -0,0 +1,36 @@
+package reports
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func ExamplePeriodSums() {
+	var root tiktak.Task
+	tl, _ := tiktak.Read(strings.NewReader(`2023-12-29T08:00:00Z /acme/dev
+2023-12-29T12:00:00Z /acme/ops
+2023-12-29T13:00:00Z
+2024-01-02T08:00:00Z /acme/dev
+2024-01-02T10:30:00Z`), &root)
+	rept := PeriodSums{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+		},
+		Periods: tiktak.QuarterBuckets{Location: time.UTC},
+	}
+	rept.Write(os.Stdout, tl, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	// Output:
+	// PERIODS: 2023-Q4 – 2024-Q1;;;;
+	// ;;;;
+	// ;Task;2023-Q4;2024-Q1;Total
+	// ;;;;
+	// ;/acme/dev;04:00;02:30;06:30
+	// ;/acme/ops;01:00;-;01:00
+	// ;/acme;05:00;02:30;07:30
+	// ;/;05:00;02:30;07:30
+}
@@ -3,6 +3,7 @@ package reports
 import (
 	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"git.fractalqb.de/fractalqb/tetrta"
@@ -11,8 +12,16 @@ import (
 
 type Sheet struct {
 	Report
-	WeekStart time.Weekday
-	Tasks     []*tiktak.Task
+	Tasks []*tiktak.Task
+	// Tracks are the names of tracks that get a column of their own
+	Tracks []string
+	// Root is the task tree of the tracks. Nil means the root of the time
+	// line.
+	Root *tiktak.Task
+	// Calendar marks holidays and days that are no workdays. With a
+	// calendar the sheet also reports the work per workday.
+	Calendar *tiktak.Calendar
+	Verbose  bool
 }
 
 type tsum struct {
@@ -45,17 +54,44 @@ func accounts(tasks []*tiktak.Task) (sums []tsum, tmap map[*tiktak.Task]*tiktak.
 }
 
 func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
-	if len(tl) == 0 {
+	root := sht.Root
+	if root == nil {
+		root = tl.RootTask()
+	}
+	var first, last time.Time
+	if len(tl) > 0 {
+		first, last = tl[0].When(), tl[len(tl)-1].When()
+	}
+	var tracks []tiktak.TimeLine
+	if root != nil {
+		for _, n := range sht.Tracks {
+			ttl := root.Track(n)
+			if ttl == nil || len(*ttl) == 0 {
+				tracks = append(tracks, nil)
+				continue
+			}
+			tracks = append(tracks, *ttl)
+			if t := (*ttl)[0].When(); first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t := (*ttl)[len(*ttl)-1].When(); t.After(last) {
+				last = t
+			}
+		}
+	}
+	if first.IsZero() {
 		return
 	}
-	loc := time.Local
+	loc := sht.loc()
+	weeks := sht.weeks()
+	now = now.In(loc)
 	fmts := sht.Fmts
 	if fmts == nil {
 		fmts = MinutesFmts
 	}
 
-	end := tiktak.StartDay(tl[len(tl)-1].When(), 1, loc)
-	day := tiktak.StartDay(tl[0].When(), 0, loc)
+	end := tiktak.StartDay(last, 1, loc)
+	day := tiktak.StartDay(first, 0, loc)
 
 	var tbl tetrta.Table
 	crsr := tbl.At(0, 0).
@@ -71,8 +107,11 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 	if len(sht.Tasks) > 0 {
 		crsr.SetString("Rest", tetrta.Left, Bold())
 	}
+	for _, n := range sht.Tracks {
+		crsr.SetString("@"+n, tetrta.Left, Bold())
+	}
 	weekSep := func(t time.Time) {
-		_, w := t.ISOWeek()
+		_, w := weeks.Week(t, loc)
 		crsr.SetString(
 			fmt.Sprintf(" Week %d ", w),
 			tetrta.SpanAll,
@@ -81,21 +120,56 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 			Muted(),
 		).NextRow()
 	}
-	if day.Weekday() != sht.WeekStart {
+	if day.Weekday() != weeks.Start {
 		crsr.NextRow()
 		weekSep(day)
 	} else {
 		crsr.NextRow()
 	}
 	tsums, tmap := accounts(sht.Tasks)
+	accTasks := make(map[*tiktak.Task][]*tiktak.Task)
+	for t, acc := range tmap {
+		accTasks[acc] = append(accTasks[acc], t)
+	}
+	cube := tiktak.Aggregate(tl, day, end, now, tiktak.DayBuckets{Location: loc})
+	tcubes := make([]*tiktak.Cube, len(tracks))
+	for i, ttl := range tracks {
+		tcubes[i] = tiktak.Aggregate(ttl, day, end, now, tiktak.DayBuckets{Location: loc})
+	}
 	tsumw := make([]time.Duration, len(tsums))
+	trkSums, trkWeek := make([]tsum, len(tracks)), make([]time.Duration, len(tracks))
+	trkDay := make([]time.Duration, len(tracks))
+	trackCells := func(ds []time.Duration, style tetrta.Styler) {
+		for _, d := range ds {
+			if d > 0 {
+				crsr.SetString(fmts.Duration(d), style)
+			} else {
+				crsr.SetString("-", style, tetrta.Center)
+			}
+		}
+	}
+	// dayStyle mutes days that are no workdays
+	dayStyle := func(day time.Time, style tetrta.Styler) tetrta.Styler {
+		if sht.Calendar == nil || sht.Calendar.Workday(day) {
+			return style
+		}
+		return tetrta.AddStyles(style, Muted())
+	}
+	holiday := func(day time.Time) {
+		if sht.Calendar == nil {
+			return
+		}
+		if name, ok := sht.Calendar.Holiday(day); ok {
+			crsr.SetString("")
+			crsr.SetString(name, tetrta.SpanAll, tetrta.Left, Muted()).NextRow()
+		}
+	}
 	count, stopCount, weekCount := 0, 0, 0
 	var workSum, breakSum, restSum time.Duration
 	var weekWork, weekBreak, weekRest time.Duration
 	var starts, stops time.Duration
-	var notes []int
 	weekSums := func() {
-		if weekWork == 0 {
+		if weekWork == 0 && !slices.ContainsFunc(trkWeek, func(d time.Duration) bool { return d > 0 }) {
 			return
 		}
 		crsr.SetString("Week count:", Muted()).Set(weekCount, Muted()).
@@ -117,44 +191,72 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 		} else if len(tsumw) > 0 {
 			crsr.SetString("-", tetrta.Center, Muted())
 		}
+		trackCells(trkWeek, Muted())
+		clear(trkWeek)
 		crsr.NextRow()
 		weekWork, weekBreak, weekRest, weekCount = 0, 0, 0, 0
 	}
 	for day.Before(end) {
-		if day.Weekday() == sht.WeekStart {
+		if day.Weekday() == weeks.Start {
 			weekSums()
 			weekSep(day)
 		}
 
 		style := tetrta.NoStyle()
 		next := tiktak.StartDay(day, 1, loc)
-		dayWork, ds, de := tl.Duration(day, next, now, tiktak.AnyTask)
+		work := cube.Get(root, true, 0, day)
+		dayWork, ds, de := work.Duration, work.Start, work.End
+		trkAny := false
+		for i, tc := range tcubes {
+			trkDay[i] = tc.Get(root, true, 0, day).Duration
+			if trkDay[i] > 0 {
+				trkAny = true
+				trkWeek[i] += trkDay[i]
+				trkSums[i].n++
+				trkSums[i].d += trkDay[i]
+			}
+		}
 		if dayWork == 0 {
+			if trkAny {
+				crsr.SetString(fmts.ShortDate(day), Muted())
+				for range 4 + len(sht.Tasks) {
+					crsr.SetString("-", tetrta.Center, Muted())
+				}
+				if len(sht.Tasks) > 0 {
+					crsr.SetString("-", tetrta.Center, Muted())
+				}
+				trackCells(trkDay, tetrta.NoStyle())
+				crsr.NextRow()
+				holiday(day)
+				if sht.Verbose && root != nil {
+					noteRows(crsr, root.DayNotes(tiktak.DateOf(day)))
+				}
+			} else if sht.Calendar != nil && sht.Calendar.WorkWeekday(day.Weekday()) {
+				if name, ok := sht.Calendar.Holiday(day); ok {
+					crsr.SetString(fmts.ShortDate(day), Muted()).
+						SetString(name, tetrta.SpanAll, tetrta.Left, Muted()).NextRow()
+				}
+			}
 			day = next
 			continue
 		}
 		stop := "..."
-		if !de.IsZero() {
-			stop = fmts.Clock(de)
-			stops += tiktak.ClockOf(de).Dur
+		if !work.Open {
+			stop = fmts.Clock(de.In(loc))
+			stops += tiktak.ClockOf(de.In(loc)).Dur
 			stopCount++
 		} else {
 			style = Bold()
 		}
-		var dayBreak time.Duration
-		if de.IsZero() {
-			dayBreak, _, _ = tl.Duration(ds, now, now, tiktak.IsATask(nil))
-		} else {
-			dayBreak, _, _ = tl.Duration(ds, de, now, tiktak.IsATask(nil))
-		}
+		dayBreak := de.Sub(ds) - dayWork
 
-		if hasWarning(tl, day, next, tiktak.AnyTask) {
+		if work.Warning || cube.Get(nil, false, 0, day).Warning {
 			crsr.SetString(fmts.ShortDate(day), tetrta.AddStyles(style, Warn()))
 		} else {
-			crsr.SetString(fmts.ShortDate(day), style)
+			crsr.SetString(fmts.ShortDate(day), dayStyle(day, style))
 		}
 
-		crsr.With(style).SetStrings(fmts.Clock(ds), stop)
+		crsr.With(style).SetStrings(fmts.Clock(ds.In(loc)), stop)
 		if dayBreak > 0 {
 			crsr.SetString(fmts.Duration(dayBreak), style)
 		} else {
@@ -164,22 +266,18 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 
 		rest := dayWork
 		for i, t := range sht.Tasks {
+			var td time.Duration
 			warns := false
-			td, _, _ := tl.Duration(day, next, now, func(s *tiktak.Switch) bool {
-				st := s.Task()
-				if st == nil {
-					if t == nil {
-						notes := s.SelectNotes(notes[:0], tiktak.Warning)
-						warns = warns || len(notes) > 0
-						return true
-					}
-				} else if tmap[st] == t {
-					notes := s.SelectNotes(notes[:0], tiktak.Warning)
-					warns = warns || len(notes) > 0
-					return true
+			if t == nil {
+				c := cube.Get(nil, false, 0, day)
+				td, warns = c.Duration, c.Warning
+			} else {
+				for _, at := range accTasks[t] {
+					c := cube.Get(at, false, 0, day)
+					td += c.Duration
+					warns = warns || c.Warning
 				}
-				return false
-			})
+			}
 			if td == 0 {
 				crsr.SetString("-", style, tetrta.Center)
 			} else {
@@ -198,9 +296,10 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 			crsr.SetString(fmts.Duration(rest), style)
 			weekRest += rest
 		}
+		trackCells(trkDay, style)
 
 		count++
-		starts += tiktak.ClockOf(ds).Dur
+		starts += tiktak.ClockOf(ds.In(loc)).Dur
 		weekWork += dayWork
 		weekBreak += dayBreak
 		workSum += dayWork
@@ -211,20 +310,30 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 		}
 
 		crsr.NextRow()
+		holiday(day)
+		if sht.Verbose && root != nil {
+			noteRows(crsr, root.DayNotes(tiktak.DateOf(day)))
+		}
 		day = next
 	}
 	weekSums()
 	var startAvg, stopAvg tiktak.Clock
 	if count > 0 {
-		startAvg = tiktak.Clock{Dur: starts / time.Duration(count), Location: now.Location()}
+		startAvg = tiktak.Clock{Dur: starts / time.Duration(count), Location: loc}
 	}
 	if stopCount > 0 {
-		stopAvg = tiktak.Clock{Dur: stops / time.Duration(stopCount), Location: now.Location()}
+		stopAvg = tiktak.Clock{Dur: stops / time.Duration(stopCount), Location: loc}
 	}
 	crsr.SetString("", tetrta.SpanAll, tetrta.CellPad('-')).NextRow().
-		SetString("Average:", tetrta.Right, Bold()).
-		SetStrings(fmts.Clock(startAvg.On(now)), fmts.Clock(stopAvg.On(now))).
-		SetStrings(fmts.Duration(breakSum/time.Duration(count)), fmts.Duration(workSum/time.Duration(count)))
+		SetString("Average:", tetrta.Right, Bold())
+	if count > 0 {
+		crsr.SetStrings(fmts.Clock(startAvg.On(now)), fmts.Clock(stopAvg.On(now))).
+			SetStrings(fmts.Duration(breakSum/time.Duration(count)), fmts.Duration(workSum/time.Duration(count)))
+	} else {
+		for range 4 {
+			crsr.SetString("-", tetrta.Center)
+		}
+	}
 	for _, ts := range tsums {
 		if ts.n > 0 {
 			crsr.SetString(fmts.Duration(ts.d / time.Duration(ts.n)))
@@ -239,6 +348,13 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 			crsr.SetString("-", tetrta.Center)
 		}
 	}
+	for _, ts := range trkSums {
+		if ts.n > 0 {
+			crsr.SetString(fmts.Duration(ts.d / time.Duration(ts.n)))
+		} else {
+			crsr.SetString("-", tetrta.Center)
+		}
+	}
 	crsr.NextRow().
 		SetString("Count:", tetrta.Right, Bold()).Set(count).
 		SetString("Sum:", Bold()).
@@ -253,6 +369,30 @@ func (sht *Sheet) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 			crsr.SetString("-", tetrta.Center)
 		}
 	}
+	for _, ts := range trkSums {
+		crsr.SetString(fmts.Duration(ts.d), Underline())
+	}
+	if sht.Calendar != nil {
+		wdays := sht.Calendar.CountWorkdays(tiktak.StartDay(first, 0, loc), end)
+		crsr.NextRow().
+			SetString("Workdays:", tetrta.Right, Bold()).Set(wdays).
+			SetString("Per day:", Bold()).SetString("")
+		if wdays > 0 {
+			crsr.SetString(fmts.Duration(workSum / time.Duration(wdays)))
+		} else {
+			crsr.SetString("-", tetrta.Center)
+		}
+	}
+
+	if sht.Verbose {
+		crsr.NextRow()
+		for _, t := range sht.Tasks {
+			if len(t.Notes()) > 0 {
+				crsr.SetString(t.String(), tetrta.SpanAll, Bold()).NextRow()
+				noteRows(crsr, t.Notes())
+			}
+		}
+	}
 
 	for i := 1; i < tbl.Columns(); i++ {
 		tbl.Align(tetrta.Right, i)
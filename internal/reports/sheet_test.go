@@ -0,0 +1,35 @@
+package reports
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func ExampleSheet_tracksOnly() {
+	var root tiktak.Task
+	tl, _ := tiktak.Read(strings.NewReader(`@oncall 2024-01-02T20:00:00Z /oncall
+@oncall 2024-01-02T22:00:00Z`), &root)
+	rept := Sheet{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+		},
+		Tracks: []string{"oncall"},
+		Root:   &root,
+	}
+	rept.Write(os.Stdout, tl, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	// Output:
+	// SHEET: Tue, 02 Jan 2024 – Tue, 02 Jan 2024;;;;;
+	// ;;;;;
+	// Day;Start;Stop;Break;Work;@oncall
+	//  Week 1 ;;;;;
+	// Tue, 02 Jan;-;-;-;-;02:00
+	// Week count:;0;Sum:;00:00;00:00;02:00
+	// ;;;;;
+	// Average:;-;-;-;-;02:00
+	// Count:;0;Sum:;00:00;00:00;02:00
+}
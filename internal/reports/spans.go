@@ -13,6 +13,9 @@ import (
 
 type Spans struct {
 	Report
+	// Root is the task tree with the day notes. Nil means the root of the
+	// time line.
+	Root    *tiktak.Task
 	Verbose bool
 }
 
@@ -21,42 +24,65 @@ func (spans *Spans) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 	if fmts == nil {
 		fmts = MinutesFmts
 	}
-	today := tiktak.DateOf(now)
+	loc := spans.loc()
+	weeks := spans.weeks()
+	today := tiktak.DateIn(now, loc)
 	var (
 		tbl tetrta.Table
 		day tiktak.Date
 	)
 	crsr := tbl.At(0, 0)
-	for i, s := range tl {
-		if s.Task() == nil && s.Next() == nil {
-			continue
+	root := spans.Root
+	if root == nil {
+		root = tl.RootTask()
+	}
+	var noteDays []tiktak.Date
+	if spans.Verbose && root != nil {
+		noteDays = root.NoteDays()
+	}
+	dayHead := func(d tiktak.Date) {
+		style := Underline()
+		if d.Compare(&today) == 0 {
+			style = tetrta.Styles{Bold(), Underline()}
 		}
-		sday := tiktak.DateOf(s.When())
-		if sday.Compare(&day) != 0 {
-			style := Underline()
-			if sday.Compare(&today) == 0 {
-				style = tetrta.Styles{Bold(), Underline()}
+		t := time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+		_, week := weeks.Week(t, loc)
+		crsr.SetString(fmt.Sprintf("%s; Week %d", fmts.Date(t), week), tetrta.SpanAll, style).NextRow()
+		if spans.Verbose && root != nil {
+			noteRows(crsr, root.DayNotes(d))
+		}
+	}
+	// noteDaysTo writes the days with notes before d, which have no spans.
+	// A nil d writes all remaining days.
+	noteDaysTo := func(d *tiktak.Date) {
+		for len(noteDays) > 0 && (d == nil || noteDays[0].Compare(d) <= 0) {
+			if d == nil || noteDays[0].Compare(d) < 0 {
+				dayHead(noteDays[0])
 			}
-			_, week := s.When().ISOWeek()
-			d := fmt.Sprintf("%s; Week %d", fmts.Date(s.When()), week)
-			crsr.SetString(d, tetrta.SpanAll, style).NextRow()
+			noteDays = noteDays[1:]
+		}
+	}
+	taskSeen := make(map[*tiktak.Task]bool)
+	for s := range tl.Spans(time.Time{}, time.Time{}, now) {
+		s.Start, s.End = s.Start.In(loc), s.End.In(loc)
+		sday := tiktak.DateOf(s.Start)
+		if sday.Compare(&day) != 0 {
+			noteDaysTo(&sday)
+			dayHead(sday)
 			day = sday
 		}
 		end := "..."
 		style := Bold()
-		var dur string
-		if ns := s.Next(); ns == nil {
-			dur = fmts.Duration(now.Sub(s.When()))
-		} else {
-			end = fmts.Clock(ns.When())
-			dur = fmts.Duration(ns.When().Sub(s.When()))
+		if !s.Open {
+			end = fmts.Clock(s.End)
 			style = tetrta.NoStyle()
 		}
-		if s.Task() == nil {
+		dur := fmts.Duration(s.Duration())
+		if s.Task == nil {
 			style = tetrta.AddStyles(style, Muted())
 		}
 		var flags []rune
-		for _, note := range s.Notes() {
+		for _, note := range s.Notes {
 			if note.Sym == 0 {
 				continue
 			}
@@ -69,31 +95,53 @@ func (spans *Spans) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 			style = tetrta.AddStyles(style, Warn())
 		}
 		if spans.Verbose {
-			crsr.SetString(SpanID(i), tetrta.Right)
+			crsr.SetString(s.Switch.ID(), tetrta.Right)
 		}
 		crsr = crsr.With(style).SetStrings(
 			string(flags),
-			fmts.Clock(s.When()),
+			fmts.Clock(s.Start),
 			end,
 			dur,
 		)
-		if s.Task() != nil {
-			crsr = crsr.SetString(s.Task().String(), style)
+		if s.Task != nil {
+			crsr = crsr.SetString(s.Task.String(), style)
+		}
+		if sw := s.Switch.When(); spans.Verbose && !sameZone(sw, loc) {
+			if s.Task == nil {
+				crsr.SetString("")
+			}
+			crsr.SetString(sw.Format("(15:04 -0700)"), Muted())
 		}
 		crsr = crsr.NextRow()
 		if spans.Verbose {
-			for _, note := range s.Notes() {
-				crsr.SetString("")
-				if note.Sym == 0 {
-					crsr.SetString(note.Text, tetrta.SpanAll, Underline())
-				} else {
-					crsr.SetString(fmt.Sprintf("%c %s", note.Sym, note.Text), tetrta.SpanAll, Underline())
-				}
-				crsr.NextRow()
+			noteRows(crsr, s.Notes)
+			if s.Task != nil && !taskSeen[s.Task] {
+				taskSeen[s.Task] = true
+				noteRows(crsr, s.Task.Notes())
 			}
 		}
 	}
+	noteDaysTo(nil)
 	tbl.Align(tetrta.Left, 0)
 	tbl.Align(tetrta.Right, 3)
 	spans.Layout.Write(w, &tbl)
 }
+
+// sameZone reports whether t was recorded with the offset loc has at t.
+func sameZone(t time.Time, loc *time.Location) bool {
+	_, off := t.Zone()
+	_, home := t.In(loc).Zone()
+	return off == home
+}
+
+func noteRows(crsr *tetrta.Cursor, notes []tiktak.Note) {
+	for _, note := range notes {
+		crsr.SetString("")
+		if note.Sym == 0 {
+			crsr.SetString(note.Text, tetrta.SpanAll, tetrta.Left, Underline())
+		} else {
+			crsr.SetString(fmt.Sprintf("%c %s", note.Sym, note.Text), tetrta.SpanAll, tetrta.Left, Underline())
+		}
+		crsr.NextRow()
+	}
+}
@@ -0,0 +1,39 @@
+package reports
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func ExampleSpans_dayNotes() {
+	var root tiktak.Task
+	tl, _ := tiktak.Read(strings.NewReader(`2024-01-01
+	. Holiday
+2024-01-02T08:00:00Z /a
+2024-01-02
+	. Day note
+2024-01-02T09:00:00Z
+2024-01-04
+	. Late note`), &root)
+	rept := Spans{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+		},
+		Root:    &root,
+		Verbose: true,
+	}
+	rept.Write(os.Stdout, tl, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	// Output:
+	// Mon, 01 Jan 2024; Week 1;;;;;
+	// ;Holiday;;;;
+	// Tue, 02 Jan 2024; Week 1;;;;;
+	// ;Day note;;;;
+	// S6MJK0-WD;;08:00;09:00;01:00;/a
+	// Thu, 04 Jan 2024; Week 1;;;;;
+	// ;Late note;;;;
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
 	"github.com/TwiN/go-color"
 )
 
@@ -35,3 +36,25 @@ func Muted() tetrta.Styler {
 func Warn() tetrta.Styler {
 	return tetrta.Style(func(s string) string { return color.OverYellow(s) })
 }
+
+var taskColors = map[string]string{
+	"black":  color.Black,
+	"red":    color.Red,
+	"green":  color.Green,
+	"yellow": color.Yellow,
+	"blue":   color.Blue,
+	"purple": color.Purple,
+	"cyan":   color.Cyan,
+	"gray":   color.Gray,
+	"white":  color.White,
+}
+
+// TaskColor returns the style for the color attribute of task t. Unknown
+// colors result in no style.
+func TaskColor(t *tiktak.Task) tetrta.Styler {
+	c, ok := taskColors[t.Color()]
+	if !ok {
+		return tetrta.NoStyle()
+	}
+	return tetrta.Style(func(s string) string { return color.Colorize(c, s) })
+}
@@ -11,7 +11,12 @@ import (
 
 type Sums struct {
 	Report
-	WeekStart time.Weekday
+	// Columns are the periods of the sum columns. Each column sums up the
+	// period that contains now. Nil means today, this week and this month.
+	Columns []tiktak.Periods
+	// Track is the name of the track that is written. Empty for the main
+	// time line.
+	Track string
 }
 
 func (sm *Sums) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
@@ -19,123 +24,150 @@ func (sm *Sums) Write(w io.Writer, tl tiktak.TimeLine, now time.Time) {
 	if troot == nil || len(tl) == 0 {
 		return
 	}
-	_, week := now.ISOWeek()
-	tsums := NewTaskSums(now, sm.WeekStart)
-	ts, te := tl[0].When(), tl[len(tl)-1].When()
-	total := ts.Before(tsums.ms)
+	loc := sm.loc()
+	now = now.In(loc)
+	weeks := sm.weeks()
+	_, week := weeks.Week(now, loc)
+	cols, heads := sm.Columns, []string{"Today", "Week", "Month"}
+	if cols == nil {
+		cols = []tiktak.Periods{
+			tiktak.DayBuckets{Location: loc},
+			tiktak.WeekBuckets{Start: weeks.Start, MinDays: weeks.MinDays, Location: loc},
+			tiktak.MonthBuckets{Location: loc},
+		}
+	} else {
+		heads = heads[:0]
+		for _, c := range cols {
+			heads = append(heads, tiktak.PeriodOf(c, now).Label())
+		}
+	}
+	// cs–ce are the times covered by the columns
+	var cs, ce time.Time
+	for i, c := range cols {
+		p := tiktak.PeriodOf(c, now)
+		if i == 0 || p.Start.Before(cs) {
+			cs = p.Start
+		}
+		if i == 0 || p.End.After(ce) {
+			ce = p.End
+		}
+	}
+	ts, te := tl[0].When().In(loc), tl[len(tl)-1].When().In(loc)
+	total := ts.Before(cs)
 	if !total {
 		sw := tl[len(tl)-1]
 		if sw.Task() == nil {
-			total = te.After(tsums.me)
+			total = te.After(ce)
 		} else {
-			total = !te.Before(tsums.me)
+			total = !te.Before(ce)
 		}
 	}
 
 	var tbl tetrta.Table
 	var caption string
+	title := "SUMS"
+	if sm.Track != "" {
+		title = "SUMS @" + sm.Track
+	}
 	if total {
-		caption = fmt.Sprintf("SUMS: %s; Week %d [%s – %s]:",
+		caption = fmt.Sprintf("%s: %s; Week %d [%s – %s]:",
+			title,
 			sm.Fmts.Date(now),
 			week,
 			sm.Fmts.Date(ts),
 			sm.Fmts.Date(te),
 		)
 	} else {
-		caption = fmt.Sprintf("SUMS: %s; Week %d:", sm.Fmts.Date(now), week)
+		caption = fmt.Sprintf("%s: %s; Week %d:", title, sm.Fmts.Date(now), week)
 	}
 	crsr := tbl.At(0, 0).
 		SetString(caption, tetrta.SpanAll, Bold()).NextRow().
 		SetString("", tetrta.SpanAll, tetrta.CellPad('-')).NextRow().
-		With(tetrta.Left).SetStrings("", "Task", "Today.", "Today/", "Week.", "Week/", "Month.", "Month/")
+		With(tetrta.Left).SetStrings("", "Task")
+	for _, h := range heads {
+		crsr.With(tetrta.Left).SetStrings(h+".", h+"/")
+	}
 	if total {
 		crsr.With(tetrta.Left).SetStrings("Total.", "Total/")
 	}
 	crsr.NextRow().
 		SetString("", tetrta.SpanAll, tetrta.CellPad('-')).NextRow()
 
+	buckets := make([]tiktak.Buckets, 0, len(cols)+1)
+	for _, c := range cols {
+		buckets = append(buckets, c)
+	}
+	dimTotal := len(cols)
+	cube := tiktak.Aggregate(tl, time.Time{}, time.Time{}, now,
+		append(buckets, tiktak.TotalBucket{})...,
+	)
 	troot.Visit(false, func(t *tiktak.Task) error {
 		var markers string
-		tsums.Of(tl, t, sm.Fmts)
 		style1 := tetrta.NoStyle()
-		if tsums.Open {
-			style1 = Bold()
-			markers = ">"
+		for dim := range cols {
+			if cube.Get(t, false, dim, now).Open {
+				style1 = Bold()
+				markers = ">"
+				break
+			}
 		}
 		styleSub := style1
 		if t.Root() == t {
 			styleSub = tetrta.AddStyles(styleSub, Underline())
 		}
-		crsr.With(style1).SetStrings(markers, t.String())
-
-		warn1 := hasWarning(tl, tsums.ds, tsums.de, tiktak.SameTask(t))
-		if tsums.Day1 == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warn1 {
-			crsr.SetString(tsums.Day1, tetrta.AddStyles(style1, Warn()))
-		} else {
-			crsr.SetString(tsums.Day1, style1)
-		}
-		warnSub := hasWarning(tl, tsums.ds, tsums.de, tiktak.IsATask(t))
-		if tsums.DaySub == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warnSub {
-			crsr.SetString(tsums.DaySub, tetrta.AddStyles(styleSub, Warn()))
-		} else {
-			crsr.SetString(tsums.DaySub, styleSub)
-		}
-		warn1 = warn1 || hasWarning(tl, tsums.ws, tsums.we, tiktak.SameTask(t))
-		if tsums.Week1 == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warn1 {
-			crsr.SetString(tsums.Week1, tetrta.AddStyles(style1, Warn()))
-		} else {
-			crsr.SetString(tsums.Week1, style1)
-		}
-		warnSub = warnSub || hasWarning(tl, tsums.ws, tsums.we, tiktak.IsATask(t))
-		if tsums.WeekSub == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warnSub {
-			crsr.SetString(tsums.WeekSub, tetrta.AddStyles(styleSub, Warn()))
-		} else {
-			crsr.SetString(tsums.WeekSub, styleSub)
-		}
-		warn1 = warn1 || hasWarning(tl, tsums.ms, tsums.me, tiktak.SameTask(t))
-		if tsums.Month1 == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warn1 {
-			crsr.SetString(tsums.Month1, tetrta.AddStyles(style1, Warn()))
-		} else {
-			crsr.SetString(tsums.Month1, style1)
+		if t.Closed() {
+			markers += "x"
 		}
-		warnSub = warnSub || hasWarning(tl, tsums.ms, tsums.me, tiktak.IsATask(t))
-		if tsums.MonthSub == empty {
-			crsr.SetString(empty, tetrta.Center)
-		} else if warnSub {
-			crsr.SetString(tsums.MonthSub, tetrta.AddStyles(styleSub, Warn()))
-		} else {
-			crsr.SetString(tsums.MonthSub, styleSub)
+		styleTask := tetrta.AddStyles(style1, TaskColor(t))
+		if b, ok := t.Budget(); ok && cube.Get(t, true, dimTotal, now).Duration > b {
+			markers += "!"
+			styleTask = tetrta.AddStyles(styleTask, Warn())
 		}
-		if total {
-			warn1 = warn1 || hasWarning(tl, ts, te, tiktak.SameTask(t))
-			d, _, _ := tl.Duration(ts, te, now, tiktak.SameTask(t))
-			if d == 0 {
+		crsr.SetString(markers, style1).SetString(t.String(), styleTask)
+
+		var warn1, warnSub bool
+		cell := func(dim int, s1, sSub string) {
+			warn1 = warn1 || cube.Get(t, false, dim, now).Warning
+			if s1 == empty {
 				crsr.SetString(empty, tetrta.Center)
 			} else if warn1 {
-				crsr.SetString(sm.Fmts.Duration(d), tetrta.AddStyles(style1, Warn()))
+				crsr.SetString(s1, tetrta.AddStyles(style1, Warn()))
 			} else {
-				crsr.SetString(sm.Fmts.Duration(d), style1)
+				crsr.SetString(s1, style1)
 			}
-			warnSub = warnSub || hasWarning(tl, ts, te, tiktak.IsATask(t))
-			d, _, _ = tl.Duration(ts, te, now, tiktak.IsATask(t))
-			if d == 0 {
+			warnSub = warnSub || cube.Get(t, true, dim, now).Warning
+			if sSub == empty {
 				crsr.SetString(empty, tetrta.Center)
 			} else if warnSub {
-				crsr.SetString(sm.Fmts.Duration(d), tetrta.AddStyles(styleSub, Warn()))
+				crsr.SetString(sSub, tetrta.AddStyles(styleSub, Warn()))
 			} else {
-				crsr.SetString(sm.Fmts.Duration(d), styleSub)
+				crsr.SetString(sSub, styleSub)
 			}
 		}
+		for dim := range cols {
+			s1, sSub := empty, empty
+			if d := cube.Get(t, false, dim, now).Duration; d > 0 {
+				s1 = sm.Fmts.Duration(d)
+			}
+			if len(t.Subtasks()) > 0 {
+				if d := cube.Get(t, true, dim, now).Duration; d > 0 {
+					sSub = sm.Fmts.Duration(d)
+				}
+			}
+			cell(dim, s1, sSub)
+		}
+		if total {
+			d1 := cube.Get(t, false, dimTotal, now).Duration
+			dSub := cube.Get(t, true, dimTotal, now).Duration
+			s1, sSub := empty, empty
+			if d1 != 0 {
+				s1 = sm.Fmts.Duration(d1)
+			}
+			if dSub != 0 {
+				sSub = sm.Fmts.Duration(dSub)
+			}
+			cell(dimTotal, s1, sSub)
+		}
 
 		crsr.NextRow()
 		return nil
@@ -152,42 +184,69 @@ type TaskSums struct {
 	Month1, MonthSub string
 	Open             bool
 
-	now    time.Time
-	ds, de time.Time
-	ws, we time.Time
-	ms, me time.Time
+	now time.Time
+	sow time.Weekday
+	loc *time.Location
+	ms  time.Time
+	me  time.Time
 }
 
-func NewTaskSums(now time.Time, sow time.Weekday) *TaskSums {
-	res := &TaskSums{
+// Dimensions of the cube computed by [TaskSums.Aggregate]
+const (
+	sumsDay = iota
+	sumsWeek
+	sumsMonth
+	sumsTotal
+)
+
+// NewTaskSums prepares sums for the day, week and month of now in location
+// loc. A nil loc means local time.
+func NewTaskSums(now time.Time, sow time.Weekday, loc *time.Location) *TaskSums {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &TaskSums{
 		now: now,
-		ds:  tiktak.StartDay(now, 0, time.Local),
-		de:  tiktak.StartDay(now, 1, time.Local),
-		ms:  tiktak.StartMonth(now, 0, time.Local),
-		me:  tiktak.StartMonth(now, 1, time.Local),
+		sow: sow,
+		loc: loc,
+		ms:  tiktak.StartMonth(now, 0, loc),
+		me:  tiktak.StartMonth(now, 1, loc),
 	}
-	res.ws = tiktak.LastDay(sow, res.ds, time.Local)
-	res.we = tiktak.NextDay(sow, res.ds, time.Local)
-	return res
+}
+
+// Aggregate computes the cube of day, week, month and total sums of tl that
+// is used by [TaskSums.FromCube].
+func (ts *TaskSums) Aggregate(tl tiktak.TimeLine) *tiktak.Cube {
+	return tiktak.Aggregate(tl, time.Time{}, time.Time{}, ts.now,
+		tiktak.DayBuckets{Location: ts.loc},
+		tiktak.WeekBuckets{Start: ts.sow, Location: ts.loc},
+		tiktak.MonthBuckets{Location: ts.loc},
+		tiktak.TotalBucket{},
+	)
 }
 
 func (ts *TaskSums) Of(tl tiktak.TimeLine, t *tiktak.Task, fmts Formats) {
-	forInterval := func(s, e time.Time, open bool) (i1, is string, io bool) {
+	ts.FromCube(ts.Aggregate(tl), t, fmts)
+}
+
+// FromCube sets the sums of task t from cube, which must be computed with
+// [TaskSums.Aggregate].
+func (ts *TaskSums) FromCube(cube *tiktak.Cube, t *tiktak.Task, fmts Formats) {
+	forInterval := func(dim int, open bool) (i1, is string, io bool) {
 		i1, is = empty, empty
-		d, ds, de := tl.Duration(s, e, ts.now, tiktak.SameTask(t))
-		open = open || (!ds.IsZero() && de.IsZero())
-		if d > 0 {
-			i1 = fmts.Duration(d)
+		c := cube.Get(t, false, dim, ts.now)
+		open = open || c.Open
+		if c.Duration > 0 {
+			i1 = fmts.Duration(c.Duration)
 		}
 		if t != nil && len(t.Subtasks()) > 0 {
-			d, _, _ = tl.Duration(s, e, ts.now, tiktak.IsATask(t))
-			if d > 0 {
+			if d := cube.Get(t, true, dim, ts.now).Duration; d > 0 {
 				is = fmts.Duration(d)
 			}
 		}
 		return i1, is, open
 	}
-	ts.Day1, ts.DaySub, ts.Open = forInterval(ts.ds, ts.de, false)
-	ts.Week1, ts.WeekSub, ts.Open = forInterval(ts.ws, ts.we, ts.Open)
-	ts.Month1, ts.MonthSub, ts.Open = forInterval(ts.ms, ts.me, ts.Open)
+	ts.Day1, ts.DaySub, ts.Open = forInterval(sumsDay, false)
+	ts.Week1, ts.WeekSub, ts.Open = forInterval(sumsWeek, ts.Open)
+	ts.Month1, ts.MonthSub, ts.Open = forInterval(sumsMonth, ts.Open)
 }
@@ -0,0 +1,42 @@
+package reports
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"git.fractalqb.de/fractalqb/tetrta"
+	"git.fractalqb.de/fractalqb/tiktak"
+)
+
+func ExampleSums_columns() {
+	tl, _ := tiktak.Read(strings.NewReader(`2024-01-02T08:00:00Z /acme/dev
+2024-01-02T12:00:00Z
+2024-01-10T08:00:00Z /acme/ops
+2024-01-10T09:30:00Z`), nil)
+	rept := Sums{
+		Report: Report{
+			Layout:   &tetrta.CSV{FS: ";"},
+			Location: time.UTC,
+			Fmts:     MinutesFmts,
+		},
+		Columns: []tiktak.Periods{
+			tiktak.CycleBuckets{
+				Weeks:    2,
+				Anchor:   time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+				Location: time.UTC,
+			},
+			tiktak.QuarterBuckets{Location: time.UTC},
+		},
+	}
+	rept.Write(os.Stdout, tl, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC))
+	// Output:
+	// SUMS: Thu, 11 Jan 2024; Week 2:;;;;;
+	// ;;;;;
+	// ;Task;2024-01-08.;2024-01-08/;2024-Q1.;2024-Q1/
+	// ;;;;;
+	// ;/acme/dev;-;-;04:00;-
+	// ;/acme/ops;01:30;-;01:30;-
+	// ;/acme;-;01:30;-;05:30
+	// ;/;-;01:30;-;05:30
+}
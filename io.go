@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -14,21 +16,45 @@ import (
 )
 
 const (
-	FileVersion = "1.0.0"
+	FileVersion = "1.4.0"
 	IOTimeFmt   = time.RFC3339
 )
 
+// Minimum file versions for format features
+const (
+	attrsFileVersion = "v1.1.0"
+	notesFileVersion = "v1.2.0"
+	quoteFileVersion = "v1.4.0"
+)
+
+const dayNoteFmt = "2006-01-02"
+
 func Write(w io.Writer, tl TimeLine) error {
+	return WriteTree(w, tl.RootTask(), tl)
+}
+
+// WriteTree writes tl like [Write] but writes the tasks, day notes and tracks
+// of the task tree root. Unlike Write, this also works for time lines without
+// task switches.
+func WriteTree(w io.Writer, root *Task, tl TimeLine) error {
+	ew := &errWriter{w: w}
+	w = ew
 	fmt.Fprintf(w, "v%s\ttiktak time tracker\n", FileVersion)
-	if root := tl.FirstTask().Root(); root != nil {
+	if root != nil {
 		var wrTasks func(*Task)
 		wrTasks = func(t *Task) {
-			if len(t.subs) == 0 || t.Title() != "" {
+			if len(t.subs) == 0 || t.Title() != "" || len(t.attrs) > 0 || len(t.notes) > 0 {
+				line := appendPath(nil, t)
+				if len(t.attrs) > 0 {
+					line = append(line, ' ')
+					line = t.appendAttrs(line)
+				}
 				if t.Title() != "" {
-					fmt.Fprintln(w, t.String(), t.Title())
-				} else {
-					fmt.Fprintln(w, t.String())
+					line = append(line, ' ')
+					line = appendTitle(line, t.Title())
 				}
+				fmt.Fprintln(w, string(line))
+				writeNotes(w, t.notes)
 			}
 			for _, s := range t.subs {
 				wrTasks(s)
@@ -36,120 +62,422 @@ func Write(w io.Writer, tl TimeLine) error {
 		}
 		wrTasks(root)
 	}
-	if len(tl) == 0 {
-		return nil
+	var noteDays []Date
+	if root != nil {
+		noteDays = root.NoteDays()
+	}
+	writeNoteDay := func(d Date, header bool) {
+		if header {
+			fmt.Fprintf(w, "# %s\n", d.format("Mon, 02 Jan 2006"))
+		}
+		fmt.Fprintln(w, d.format(dayNoteFmt))
+		writeNotes(w, root.DayNotes(d))
 	}
 	var day Date
-	for _, s := range tl {
+	for _, s := range allSwitches(root, tl) {
 		sday := DateOf(s.When())
 		if sday.Compare(&day) != 0 {
+			for len(noteDays) > 0 && noteDays[0].Compare(&sday) < 0 {
+				writeNoteDay(noteDays[0], true)
+				noteDays = noteDays[1:]
+			}
 			fmt.Fprintf(w, "# %s\n", s.When().Format("Mon, 02 Jan 2006"))
 			day = sday
+			if len(noteDays) > 0 && noteDays[0].Compare(&sday) == 0 {
+				writeNoteDay(noteDays[0], false)
+				noteDays = noteDays[1:]
+			}
+		}
+		if s.track != "" {
+			fmt.Fprintf(w, "@%s ", s.track)
 		}
 		if t := s.Task(); t == nil {
 			fmt.Fprintf(w, "%s\n", s.When().Format(IOTimeFmt))
 		} else {
-			fmt.Fprintf(w, "%s %s\n", s.When().Format(IOTimeFmt), t)
+			fmt.Fprintf(w, "%s %s\n", s.When().Format(IOTimeFmt), appendPath(nil, t))
 		}
-		for _, note := range s.notes {
-			if note.Sym == 0 {
-				fmt.Fprintf(w, "\t. %s\n", note.Text)
-			} else {
-				fmt.Fprintf(w, "\t!%c %s\n", note.Sym, note.Text)
-			}
+		writeNotes(w, s.notes)
+	}
+	for _, d := range noteDays {
+		writeNoteDay(d, true)
+	}
+	return ew.err
+}
+
+// errWriter keeps the first error of w and does not write after an error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (n int, err error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, ew.err = ew.w.Write(p)
+	return n, ew.err
+}
+
+// Since file version 1.4 task paths and titles are quoted if needed.
+
+func needsQuote(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '\\' || !strconv.IsPrint(r)
+	})
+}
+
+func appendPath(b []byte, t *Task) []byte {
+	if p := t.String(); needsQuote(p) {
+		return strconv.AppendQuote(b, p)
+	} else {
+		return append(b, p...)
+	}
+}
+
+func appendTitle(b []byte, title string) []byte {
+	if strings.HasPrefix(title, "[") ||
+		strings.HasPrefix(title, `"`) ||
+		strings.TrimSpace(title) != title ||
+		strings.ContainsFunc(title, func(r rune) bool { return !strconv.IsPrint(r) }) {
+		return strconv.AppendQuote(b, title)
+	}
+	return append(b, title...)
+}
+
+func writeNotes(w io.Writer, notes []Note) {
+	for _, note := range notes {
+		if note.Sym == 0 {
+			fmt.Fprintf(w, "\t. %s\n", noteText(note.Text))
+		} else {
+			fmt.Fprintf(w, "\t!%c %s\n", note.Sym, noteText(note.Text))
 		}
 	}
-	return nil
 }
 
 var majorFileVersion = semver.Major("v" + FileVersion)
 
+// ReadError is an error or a warning at a position of the input of a
+// [Reader]. Line and Col start with 1. Line or Col are 0 if the error does
+// not refer to a specific line or column.
+type ReadError struct {
+	Line, Col int
+	Warning   bool
+	Err       error
+}
+
+func (e *ReadError) Error() string {
+	var sb strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, "%d:", e.Line)
+	}
+	if e.Col > 0 {
+		fmt.Fprintf(&sb, "%d:", e.Col)
+	}
+	if e.Warning {
+		sb.WriteString("warning:")
+	}
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+func colErr(col int, err error) *ReadError { return &ReadError{Col: col, Err: err} }
+
+func colErrf(col int, format string, args ...any) *ReadError {
+	return &ReadError{Col: col, Err: fmt.Errorf(format, args...)}
+}
+
+// Reader reads time lines with configurable error handling. The zero value
+// reads like [Read].
+type Reader struct {
+	// Lenient makes the reader skip bad lines instead of failing. The notes
+	// of skipped lines are skipped, too. All errors are collected in
+	// Diagnostics.
+	Lenient bool
+	// Strict makes warnings errors.
+	Strict bool
+	// Raw reads like [ReadRaw].
+	Raw bool
+	// Diagnostics are the errors and warnings of the last Read.
+	Diagnostics []*ReadError
+
+	root       *Task
+	tl         TimeLine
+	version    string
+	lastSwitch int
+	lastTask   *Task
+	lastDay    *Date
+	lastTL     *TimeLine
+	skipNotes  bool // notes of a skipped line follow
+}
+
 func Read(r io.Reader, root *Task) (tl TimeLine, err error) {
+	var rd Reader
+	tl, err = rd.Read(r, root)
+	rd.logWarnings()
+	return tl, err
+}
+
+// ReadRaw reads a time line without normalizing it. Each switch line of the
+// input becomes a switch in the returned time line, in input order. The
+// result is meant to be checked with [TimeLine.Validate] and to be made a
+// proper time line with [TimeLine.Normalize].
+func ReadRaw(r io.Reader, root *Task) (tl TimeLine, err error) {
+	rd := Reader{Raw: true}
+	tl, err = rd.Read(r, root)
+	rd.logWarnings()
+	return tl, err
+}
+
+func (rd *Reader) logWarnings() {
+	for _, d := range rd.Diagnostics {
+		if d.Warning {
+			log.Println(d)
+		}
+	}
+}
+
+// Errors returns the number of diagnostics that are not warnings.
+func (rd *Reader) Errors() (n int) {
+	for _, d := range rd.Diagnostics {
+		if !d.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// Read reads a time line from r into the task tree root. If rd is not
+// lenient, Read stops at the first error, which is a *[ReadError]. A lenient
+// Read only fails if r fails.
+func (rd *Reader) Read(r io.Reader, root *Task) (TimeLine, error) {
 	if root == nil {
 		root = new(Task)
 	}
-	lno := 0
+	*rd = Reader{
+		Lenient:    rd.Lenient,
+		Strict:     rd.Strict,
+		Raw:        rd.Raw,
+		root:       root,
+		version:    "v" + FileVersion,
+		lastSwitch: -1,
+	}
+	rd.lastTL = &rd.tl
 	scn := bufio.NewScanner(r)
-	lastSwitch := -1
-	for scn.Scan() {
-		lno++
+	for lno := 1; scn.Scan(); lno++ {
 		line := scn.Text()
 		if len(line) == 0 || line[0] == '#' {
 			continue
 		}
-		switch line[0] {
-		case '/':
-			sep := strings.IndexAny(line, " \t")
-			if sep < 0 {
-				root.GetString(line)
-			} else {
-				t, err := root.GetString(line[:sep])
-				if err != nil {
-					return nil, fmt.Errorf("%d:%w", lno, err)
+		note := strings.IndexAny(line, " \t") == 0
+		if !note {
+			rd.skipNotes = false
+		}
+		if err := rd.line(line, lno); err != nil {
+			err.Line = lno
+			if err.Warning && rd.Strict {
+				err.Warning = false
+			}
+			rd.Diagnostics = append(rd.Diagnostics, err)
+			if !err.Warning {
+				if !rd.Lenient {
+					return nil, err
+				}
+				if !note {
+					rd.skipNotes = true
 				}
-				t.SetTitle(strings.TrimSpace(line[sep:]))
 			}
-		case 'v':
-			sep := strings.IndexAny(line, " \t")
-			if sep > 0 {
-				line = line[:sep]
+		}
+	}
+	if err := scn.Err(); err != nil {
+		return nil, err
+	}
+	tl := rd.tl
+	rd.root, rd.tl, rd.lastTask, rd.lastDay, rd.lastTL = nil, nil, nil, nil, nil
+	return tl, nil
+}
+
+func (rd *Reader) line(line string, lno int) *ReadError {
+	root := rd.root
+	switch line[0] {
+	case '/', '"':
+		rd.lastDay, rd.lastTask = nil, nil
+		p, rest, err := rd.splitPath(line)
+		if err != nil {
+			return colErr(1, err)
+		}
+		if p == "" || p[0] != '/' {
+			return colErrf(1, "not an absolute path '%s'", p)
+		}
+		t, err := root.GetString(p)
+		if err != nil {
+			return colErr(1, err)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+		col := len(line) - len(rest) + 1
+		rest = strings.TrimRight(rest, " \t")
+		if strings.HasPrefix(rest, "[") && semver.Compare(rd.version, attrsFileVersion) >= 0 {
+			attrs := maps.Clone(t.attrs)
+			r, err := t.parseAttrs(rest)
+			switch {
+			case err == nil:
+				col += len(rest) - len(r)
+				rest = strings.TrimLeft(r, " \t")
+				col += len(r) - len(rest)
+			case rd.quoting():
+				return colErr(col, err)
+			default:
+				// Before v1.4 titles starting with '[' were not quoted
+				t.attrs = attrs
 			}
-			if !semver.IsValid(line) {
-				return nil, fmt.Errorf("%d:syntax error in file version '%s'",
-					lno,
-					line,
-				)
+		}
+		if strings.HasPrefix(rest, `"`) && rd.quoting() {
+			if rest, err = strconv.Unquote(rest); err != nil {
+				return colErrf(col, "quoted title: %w", err)
 			}
-			major := semver.Major(line)
-			if major != majorFileVersion {
-				return nil, fmt.Errorf("%d: incompatible file version %s, current v%s",
-					lno,
+		}
+		t.SetTitle(rest)
+		rd.lastTask = t
+	case '@':
+		rd.lastTask, rd.lastDay = nil, nil
+		sep := strings.IndexByte(line, ' ')
+		if sep < 0 {
+			return colErrf(1, "track without switch")
+		}
+		track, err := root.MakeTrack(line[1:sep])
+		if err != nil {
+			return colErr(2, err)
+		}
+		t, task, rerr := rd.parseSwitch(line[sep+1:], sep+2)
+		if rerr != nil {
+			return rerr
+		}
+		rd.lastTL, rd.lastSwitch = track, track.readSwitch(t, task, lno, rd.Raw)
+	case 'v':
+		sep := strings.IndexAny(line, " \t")
+		if sep > 0 {
+			line = line[:sep]
+		}
+		if !semver.IsValid(line) {
+			return colErrf(1, "syntax error in file version '%s'", line)
+		}
+		major := semver.Major(line)
+		if major != majorFileVersion {
+			return colErrf(1, "incompatible file version %s, current v%s",
+				line,
+				FileVersion,
+			)
+		}
+		rd.version = line
+		if semver.Compare(line, "v"+FileVersion) > 0 {
+			return &ReadError{
+				Col:     1,
+				Warning: true,
+				Err: fmt.Errorf("file version %s greater than current v%s",
 					line,
 					FileVersion,
-				)
+				),
 			}
-			if semver.Compare(line, "v"+FileVersion) > 0 {
-				log.Printf("%d: file version %s greater than current v%s",
-					lno,
-					line,
-					FileVersion,
-				)
+		}
+	default:
+		if strings.IndexAny(line, " \t") == 0 {
+			col := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+			n, err := parseNote(line)
+			if err != nil {
+				return colErr(col, err)
 			}
-		default:
-			if strings.IndexAny(line, " \t") == 0 {
-				if lastSwitch < 0 {
-					return nil, fmt.Errorf("%d:note before first switch", lno)
-				}
-				n, err := parseNote(line)
-				if err != nil {
-					return nil, fmt.Errorf("%d:%w", lno, err)
-				}
-				tl[lastSwitch].notes = append(tl[lastSwitch].notes, n)
-			} else {
-				fs := strings.Split(line, " ")
-				t, err := time.Parse(IOTimeFmt, fs[0])
-				if err != nil {
-					return nil, fmt.Errorf("%d:%w", lno, err)
-				}
-				if len(fs) == 1 {
-					lastSwitch = tl.Switch(t, nil)
-					continue
-				}
-				switch {
-				case len(fs[1]) == 0:
-					return nil, fmt.Errorf("%d:empty task path", lno)
-				case fs[1][0] != '/':
-					return nil, fmt.Errorf("%d:not an absolute path '%s'", lno, fs[1])
-				}
-				task, err := root.GetString(fs[1])
-				if err != nil {
-					return nil, fmt.Errorf("%d:%w", lno, err)
-				}
-				lastSwitch = tl.Switch(t, task)
+			if rd.skipNotes {
+				return colErrf(col, "note of skipped line")
+			}
+			switch {
+			case rd.lastTask != nil && semver.Compare(rd.version, notesFileVersion) >= 0:
+				rd.lastTask.notes = append(rd.lastTask.notes, n)
+			case rd.lastDay != nil:
+				root.addDayNote(*rd.lastDay, n)
+			case rd.lastSwitch < 0:
+				return colErrf(col, "note before first switch")
+			default:
+				s := (*rd.lastTL)[rd.lastSwitch]
+				s.notes = append(s.notes, n)
 			}
+			return nil
 		}
+		rd.lastTask = nil
+		if len(line) == len(dayNoteFmt) {
+			d, err := time.Parse(dayNoteFmt, line)
+			if err != nil {
+				return colErr(1, err)
+			}
+			day := DateOf(d)
+			rd.lastDay = &day
+			return nil
+		}
+		rd.lastDay = nil
+		t, task, err := rd.parseSwitch(line, 1)
+		if err != nil {
+			return err
+		}
+		rd.lastTL, rd.lastSwitch = &rd.tl, rd.tl.readSwitch(t, task, lno, rd.Raw)
 	}
-	return tl, nil
+	return nil
+}
+
+func (rd *Reader) quoting() bool { return semver.Compare(rd.version, quoteFileVersion) >= 0 }
+
+// splitPath splits the task path at the start of s from the rest of s. The
+// path may be quoted since file version 1.4.
+func (rd *Reader) splitPath(s string) (path, rest string, err error) {
+	if strings.HasPrefix(s, `"`) && rd.quoting() {
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", fmt.Errorf("quoted path: %w", err)
+		}
+		path, _ = strconv.Unquote(q)
+		return path, s[len(q):], nil
+	}
+	if sep := strings.IndexAny(s, " \t"); sep >= 0 {
+		return s[:sep], s[sep:], nil
+	}
+	return s, "", nil
+}
+
+// parseSwitch parses the switch in line, which is at column col of the input
+func (rd *Reader) parseSwitch(line string, col int) (t time.Time, task *Task, err *ReadError) {
+	ts, rest, hasTask := strings.Cut(line, " ")
+	t, terr := time.Parse(IOTimeFmt, ts)
+	if terr != nil {
+		return t, nil, colErr(col, terr)
+	}
+	if !hasTask {
+		return t, nil, nil
+	}
+	col += len(ts) + 1
+	p, _, perr := rd.splitPath(rest)
+	switch {
+	case perr != nil:
+		return t, nil, colErr(col, perr)
+	case len(p) == 0:
+		return t, nil, colErrf(col, "empty task path")
+	case p[0] != '/':
+		return t, nil, colErrf(col, "not an absolute path '%s'", p)
+	}
+	task, terr = rd.root.GetString(p)
+	if terr != nil {
+		return t, nil, colErr(col, terr)
+	}
+	return t, task, nil
+}
+
+func (tl *TimeLine) readSwitch(t time.Time, task *Task, lno int, raw bool) int {
+	if raw {
+		return tl.appendRaw(t, task, lno)
+	}
+	i := tl.Switch(t, task)
+	if i >= 0 && (*tl)[i].line == 0 {
+		(*tl)[i].line = lno
+	}
+	return i
 }
 
 func parseNote(line string) (Note, error) {
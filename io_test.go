@@ -1,9 +1,12 @@
 package tiktak
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"testing"
+	"time"
 )
 
 func ExampleRead() {
@@ -22,7 +25,7 @@ func ExampleRead() {
 	}
 	Write(os.Stdout, ts)
 	// Output:
-	// v1.0.0	tiktak time tracker
+	// v1.4.0	tiktak time tracker
 	// /1
 	// /2
 	// /3 Just to test titles
@@ -37,3 +40,280 @@ func ExampleRead() {
 	// 2023-04-01T13:00:00Z /2
 	// 	. A note
 }
+
+func ExampleRead_attributes() {
+	ts, err := Read(strings.NewReader(`v1.1.0	tiktak time tracker
+/acme/proj [billable=true rate=95.5 color=green] Project with attributes
+/acme/proj/sub [budget=40h note="with ] bracket"]
+/acme/other [closed=true]
+2023-04-01T12:00:00Z /acme/proj/sub`), nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	sub := ts[0].Task()
+	fmt.Println(sub.Billable(), sub.Closed(), sub.Color())
+	fmt.Println(sub.Rate())
+	fmt.Println(sub.Budget())
+	Write(os.Stdout, ts)
+	v10, _ := Read(strings.NewReader(`v1.0.0	tiktak time tracker
+/old [not=an attribute] title
+2023-04-01T12:00:00Z /old`), nil)
+	fmt.Println(v10[0].Task().Title())
+	// Output:
+	// true false green
+	// 95.5 true
+	// 40h0m0s true
+	// v1.4.0	tiktak time tracker
+	// /acme/other [closed=true]
+	// /acme/proj [billable=true color=green rate=95.5] Project with attributes
+	// /acme/proj/sub [budget=40h note="with ] bracket"]
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T12:00:00Z /acme/proj/sub
+	// [not=an attribute] title
+}
+
+func ExampleRead_notes() {
+	ts, err := Read(strings.NewReader(`v1.2.0	tiktak time tracker
+/proj Project
+	. A task note
+# Fri, 31 Mar 2023
+2023-03-31
+	. A day without switches
+# Sat, 01 Apr 2023
+2023-04-01
+	. Worked from train
+2023-04-01T12:00:00Z /proj
+	. A switch note`), nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	root := ts.RootTask()
+	fmt.Println(root.DayNotes(DateOf(ts[0].When()))[0].Text)
+	root.AddDayNote(Date{Year: 2023, Month: time.April, Day: 2}, "Day off")
+	Write(os.Stdout, ts)
+	// Output:
+	// Worked from train
+	// v1.4.0	tiktak time tracker
+	// /proj Project
+	// 	. A task note
+	// # Fri, 31 Mar 2023
+	// 2023-03-31
+	// 	. A day without switches
+	// # Sat, 01 Apr 2023
+	// 2023-04-01
+	// 	. Worked from train
+	// 2023-04-01T12:00:00Z /proj
+	// 	. A switch note
+	// # Sun, 02 Apr 2023
+	// 2023-04-02
+	// 	. Day off
+}
+
+func ExampleRead_tracks() {
+	var root Task
+	tl, err := Read(strings.NewReader(`v1.3.0	tiktak time tracker
+2023-04-01T08:00:00Z /proj
+@oncall 2023-04-01T09:00:00Z /standby
+	. Pager duty
+2023-04-01T12:00:00Z
+@oncall 2023-04-01T18:00:00Z`), &root)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(root.Tracks(), len(tl), len(*root.Track("oncall")))
+	oncall, _ := root.MakeTrack("oncall")
+	oncall.Switch(time.Date(2023, 4, 1, 13, 0, 0, 0, time.UTC), root.FindString("/proj"))
+	WriteTree(os.Stdout, &root, tl)
+	// Output:
+	// [oncall] 2 2
+	// v1.4.0	tiktak time tracker
+	// /proj
+	// /standby
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T08:00:00Z /proj
+	// @oncall 2023-04-01T09:00:00Z /standby
+	// 	. Pager duty
+	// 2023-04-01T12:00:00Z
+	// @oncall 2023-04-01T13:00:00Z /proj
+	// @oncall 2023-04-01T18:00:00Z
+}
+
+func ExampleReader_lenient() {
+	rd := Reader{Lenient: true}
+	tl, err := rd.Read(strings.NewReader(`v1.99.0	tiktak time tracker
+2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00 /b
+2023-04-01T10:00:00Z b
+	? unknown note
+2023-04-01T11:00:00Z`), nil)
+	fmt.Println(len(tl), err)
+	for _, d := range rd.Diagnostics {
+		fmt.Println(d)
+	}
+	var rerr *ReadError
+	_, err = (&Reader{Strict: true}).Read(strings.NewReader("v1.99.0"), nil)
+	if errors.As(err, &rerr) {
+		fmt.Println(rerr.Line, rerr.Col, rerr.Warning)
+	}
+	// Output:
+	// 2 <nil>
+	// 1:1:warning:file version v1.99.0 greater than current v1.4.0
+	// 3:1:parsing time "2023-04-01T09:00:00" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "Z07:00"
+	// 4:22:not an absolute path 'b'
+	// 5:2:invalid note type '?'
+	// 1 1 false
+}
+
+func ExampleRead_quoted() {
+	var root Task
+	tl, err := Read(strings.NewReader(`v1.4.0	tiktak time tracker
+"/ACME Corp/Project X" [rate=95] "[Draft] Offer"
+/plain  Title with "quotes"
+2023-04-01T08:00:00Z "/ACME Corp/Project X"
+2023-04-01T09:00:00Z /plain`), &root)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	acme := root.FindString("/ACME Corp/Project X")
+	fmt.Printf("%s|%s\n", acme.Name(), acme.Title())
+	WriteTree(os.Stdout, &root, tl)
+	_, err = Read(strings.NewReader(`v1.0.0	tiktak time tracker
+2023-04-01T08:00:00Z "/ACME Corp/Project X"`), nil)
+	fmt.Println(err)
+	// Output:
+	// Project X|[Draft] Offer
+	// v1.4.0	tiktak time tracker
+	// "/ACME Corp/Project X" [rate=95] "[Draft] Offer"
+	// /plain Title with "quotes"
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T08:00:00Z "/ACME Corp/Project X"
+	// 2023-04-01T09:00:00Z /plain
+	// 2:22:not an absolute path '"/ACME'
+}
+
+type failWriter int
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if *w <= 0 {
+		return 0, errors.New("disk full")
+	}
+	*w--
+	return len(p), nil
+}
+
+func TestWriteTree_error(t *testing.T) {
+	tl, err := Read(strings.NewReader(`2023-04-01T12:00:00Z /1
+2023-04-01T13:00:00Z /2`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := failWriter(3)
+	if err := Write(&w, tl); err == nil || err.Error() != "disk full" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWrite_noteControl(t *testing.T) {
+	if err := CheckNoteText("a\nb"); err == nil {
+		t.Error("accepted newline in note")
+	}
+	if err := CheckNoteText("a\tb"); err != nil {
+		t.Error(err)
+	}
+	tl, err := Read(strings.NewReader("2023-04-01T12:00:00Z /1\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl[0].Task().AddNote("line1\nline2")
+	var sb strings.Builder
+	if err := Write(&sb, tl); err != nil {
+		t.Fatal(err)
+	}
+	tl, err = Read(strings.NewReader(sb.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns := tl[0].Task().Notes(); len(ns) != 1 || ns[0].Text != "line1 line2" {
+		t.Errorf("notes after round trip: %v", ns)
+	}
+}
+
+func TestWrite_attrControl(t *testing.T) {
+	tl, err := Read(strings.NewReader("2023-04-01T12:00:00Z /1\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := tl[0].Task()
+	if err := task.SetAttr("a\x01", "x"); err == nil {
+		t.Error("accepted control character in attribute key")
+	}
+	task.SetAttr("note", "line1\nline2")
+	var sb strings.Builder
+	if err := Write(&sb, tl); err != nil {
+		t.Fatal(err)
+	}
+	tl, err = Read(strings.NewReader(sb.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := tl[0].Task().Attr("note"); v != "line1\nline2" {
+		t.Errorf("attribute after round trip: %q", v)
+	}
+}
+
+func TestRead_bracketTitle(t *testing.T) {
+	tl, err := Read(strings.NewReader(`v1.3.0	tiktak time tracker
+/old [draft] title
+2023-04-01T12:00:00Z /old`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := tl[0].Task()
+	if title := task.Title(); title != "[draft] title" {
+		t.Errorf("title: %q", title)
+	}
+	var sb strings.Builder
+	if err := Write(&sb, tl); err != nil {
+		t.Fatal(err)
+	}
+	if tl, err = Read(strings.NewReader(sb.String()), nil); err != nil {
+		t.Fatal(err)
+	}
+	if title := tl[0].Task().Title(); title != "[draft] title" {
+		t.Errorf("title after round trip: %q", title)
+	}
+}
+
+func ExampleReader_skipNotes() {
+	var root Task
+	rd := Reader{Lenient: true}
+	tl, _ := rd.Read(strings.NewReader(`2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00 /b
+	. Note of /b
+	. Another note of /b
+/c [rate=
+	. Note of /c
+@ 2023-04-01T10:00:00Z
+	. Note of track
+2023-04-01T11:00:00Z`), &root)
+	for _, s := range tl {
+		fmt.Println(s.Task(), len(s.Notes()))
+	}
+	for _, d := range rd.Diagnostics {
+		fmt.Println(d)
+	}
+	// Output:
+	// /a 0
+	// - 0
+	// 2:1:parsing time "2023-04-01T09:00:00" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "Z07:00"
+	// 3:2:note of skipped line
+	// 4:2:note of skipped line
+	// 5:4:unterminated attribute list of task /c
+	// 6:2:note of skipped line
+	// 7:2:empty track name
+	// 8:2:note of skipped line
+}
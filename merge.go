@@ -0,0 +1,440 @@
+package tiktak
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ConflictSym is the warning symbol of notes that mark merge conflicts.
+const ConflictSym = '⚡'
+
+// Version is a time line together with the task tree it was read into.
+type Version struct {
+	Root     *Task
+	TimeLine TimeLine
+}
+
+type mergeSw struct {
+	when    time.Time
+	task    string
+	notes   []Note
+	removed bool
+}
+
+func (e *mergeSw) apply(cs []Change) {
+	for _, c := range cs {
+		switch c.Kind {
+		case Removed:
+			e.removed = true
+		case Rescheduled:
+			e.when = c.B.When()
+		case Retasked:
+			e.task = taskPath(c.B.Task())
+		case NotesChanged:
+			e.notes = c.NewNotes
+		}
+	}
+}
+
+func (e *mergeSw) String() string {
+	if e.removed {
+		return "removed"
+	}
+	return e.when.Format(IOTimeFmt) + " " + e.task
+}
+
+type conflictNote struct {
+	at   time.Time
+	text string
+}
+
+type merger struct {
+	root      *Task
+	conflicts []conflictNote
+	deleted   map[string]bool
+	n         int
+}
+
+func (m *merger) conflict(at time.Time, format string, args ...any) {
+	m.conflicts = append(m.conflicts, conflictNote{at, fmt.Sprintf(format, args...)})
+	m.n++
+}
+
+func (m *merger) taskConflict(t *Task, format string, args ...any) {
+	t.notes = append(t.notes, Note{Sym: ConflictSym, Text: fmt.Sprintf(format, args...)})
+	m.n++
+}
+
+// Merge3 merges the changes that ours and theirs made to base into a new
+// time line of the task tree root. Changes of the same switch that do not
+// agree and changes of overlapping time spans are conflicts. Tasks, task
+// notes and day notes that were removed on one side stay removed unless the
+// other side changed them, which is a conflict too. Merge3 does not
+// fail on conflicts but resolves them in favour of ours and marks them with
+// warning notes with symbol [ConflictSym]. It returns the number of
+// conflicts.
+func Merge3(root *Task, base, ours, theirs Version) (TimeLine, int) {
+	m := merger{root: root, deleted: make(map[string]bool)}
+	m.tasks(base.Root, ours.Root, theirs.Root)
+	res := m.timeLine(base.TimeLine, ours.TimeLine, theirs.TimeLine)
+	tracks := make(map[string]bool)
+	for _, v := range []Version{base, ours, theirs} {
+		if v.Root != nil {
+			for _, t := range v.Root.Tracks() {
+				tracks[t] = true
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(tracks)) {
+		track := func(v Version) TimeLine {
+			if v.Root == nil {
+				return nil
+			}
+			if tl := v.Root.Track(name); tl != nil {
+				return *tl
+			}
+			return nil
+		}
+		tl, _ := root.MakeTrack(name)
+		*tl = m.timeLine(track(base), track(ours), track(theirs))
+	}
+	return res, m.n
+}
+
+func (m *merger) tasks(base, ours, theirs *Task) {
+	m.mergeTask(m.root, base, ours, theirs, false)
+	days := make(map[Date]bool)
+	for _, r := range []*Task{base, ours, theirs} {
+		if r != nil {
+			for d := range r.Root().days {
+				days[d] = true
+			}
+		}
+	}
+	dayNotes := func(r *Task, d Date) []Note {
+		if r == nil {
+			return nil
+		}
+		return r.Root().days[d]
+	}
+	root := m.root.Root()
+	for d := range days {
+		ns := mergeNotes3(dayNotes(base, d), dayNotes(ours, d), dayNotes(theirs, d))
+		if len(ns) == 0 {
+			continue
+		}
+		if root.days == nil {
+			root.days = make(map[Date][]Note)
+		}
+		root.days[d] = ns
+	}
+}
+
+// mergeTask merges the subtasks of b, o and t into r. Each of b, o and t may
+// be nil. A subtask that was removed on one side stays removed if the other
+// side did not change it. Otherwise it is kept with a conflict note. With
+// keep, subtasks removed on only one side are kept because their parent was
+// kept.
+func (m *merger) mergeTask(r, b, o, t *Task, keep bool) {
+	names := make(map[string]bool)
+	for _, x := range []*Task{b, o, t} {
+		if x != nil {
+			for _, s := range x.subs {
+				names[s.name] = true
+			}
+		}
+	}
+	for _, n := range slices.SortedFunc(maps.Keys(names), cmprName) {
+		bs, os, ts := subTask(b, n), subTask(o, n), subTask(t, n)
+		var conflicts []string
+		subKeep := keep
+		switch {
+		case bs == nil:
+		case os == nil && ts == nil:
+			m.removed(bs)
+			continue
+		case os == nil:
+			if !keep {
+				if sameTask(bs, ts) {
+					m.removed(bs)
+					continue
+				}
+				conflicts = append(conflicts, "merge: removed by ours")
+				subKeep = true
+			}
+			os = bs
+		case ts == nil:
+			if !keep {
+				if sameTask(bs, os) {
+					m.removed(bs)
+					continue
+				}
+				conflicts = append(conflicts, "merge: removed by theirs")
+				subKeep = true
+			}
+			ts = bs
+		}
+		rs, err := r.Get(n)
+		if err != nil {
+			continue
+		}
+		title := func(t *Task) string {
+			if t == nil {
+				return ""
+			}
+			return t.title
+		}
+		var ok bool
+		if rs.title, ok = pick(title(bs), title(os), title(ts)); !ok {
+			conflicts = append(conflicts, fmt.Sprintf("merge: title of theirs is '%s'", title(ts)))
+		}
+		keys := make(map[string]bool)
+		for _, x := range []*Task{bs, os, ts} {
+			if x != nil {
+				for k := range x.attrs {
+					keys[k] = true
+				}
+			}
+		}
+		attr := func(t *Task, k string) string {
+			if t == nil {
+				return ""
+			}
+			return t.attrs[k]
+		}
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			v, ok := pick(attr(bs, k), attr(os, k), attr(ts, k))
+			if !ok {
+				conflicts = append(conflicts,
+					fmt.Sprintf("merge: attribute %s of theirs is '%s'", k, attr(ts, k)))
+			}
+			if v != "" {
+				rs.SetAttr(k, v)
+			}
+		}
+		notes := func(t *Task) []Note {
+			if t == nil {
+				return nil
+			}
+			return t.notes
+		}
+		rs.notes = mergeNotes3(notes(bs), notes(os), notes(ts))
+		for _, c := range conflicts {
+			m.taskConflict(rs, "%s", c)
+		}
+		m.mergeTask(rs, bs, os, ts, subKeep)
+	}
+}
+
+func (m *merger) removed(t *Task) {
+	t.Visit(true, func(t *Task) error {
+		m.deleted[t.String()] = true
+		return nil
+	})
+}
+
+func subTask(t *Task, name string) *Task {
+	if t == nil {
+		return nil
+	}
+	if i, ok := t.searchSub(name); ok {
+		return t.subs[i]
+	}
+	return nil
+}
+
+// sameTask reports whether a and b have the same title, attributes, notes
+// and subtasks.
+func sameTask(a, b *Task) bool {
+	if a.title != b.title || !maps.Equal(a.attrs, b.attrs) ||
+		!slices.Equal(a.notes, b.notes) || len(a.subs) != len(b.subs) {
+		return false
+	}
+	for i, s := range a.subs {
+		if s.name != b.subs[i].name || !sameTask(s, b.subs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pick selects the value of a three-way merge of base b, ours o and theirs t.
+// It returns o and false if o and t changed b differently.
+func pick(b, o, t string) (string, bool) {
+	switch {
+	case o == b || o == t:
+		return t, true
+	case t == b:
+		return o, true
+	}
+	return o, false
+}
+
+// mergeNotes appends the notes of add that are not in to
+func mergeNotes(to, add []Note) []Note {
+	for _, n := range add {
+		if !slices.Contains(to, n) {
+			to = append(to, n)
+		}
+	}
+	return to
+}
+
+// mergeNotes3 merges the notes of ours and theirs that were changed from base.
+// Notes removed on either side stay removed.
+func mergeNotes3(base, ours, theirs []Note) (res []Note) {
+	for _, n := range ours {
+		if !slices.Contains(base, n) || slices.Contains(theirs, n) {
+			res = append(res, n)
+		}
+	}
+	for _, n := range theirs {
+		if !slices.Contains(base, n) && !slices.Contains(res, n) {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+type changeSpan struct {
+	c          Change
+	start, end time.Time
+}
+
+// changeSpans computes the time spans affected by the changes cs. The span
+// of a stop is empty.
+func changeSpans(cs []Change) (res []changeSpan) {
+	end := func(s *Switch) time.Time {
+		if n := s.Next(); n != nil && s.Task() != nil {
+			return n.When()
+		}
+		return s.When()
+	}
+	for _, c := range cs {
+		var sp changeSpan
+		switch c.Kind {
+		case Added:
+			sp = changeSpan{c, c.B.When(), end(c.B)}
+		case Removed:
+			sp = changeSpan{c, c.A.When(), end(c.A)}
+		case Rescheduled, Retasked:
+			sp = changeSpan{c, c.A.When(), end(c.A)}
+			if t := c.B.When(); t.Before(sp.start) {
+				sp.start = t
+			}
+			if t := end(c.B); t.After(sp.end) {
+				sp.end = t
+			}
+		default:
+			continue
+		}
+		res = append(res, sp)
+	}
+	return res
+}
+
+func (m *merger) timeLine(base, ours, theirs TimeLine) (res TimeLine) {
+	m.conflicts = m.conflicts[:0]
+	// Task changes are merged by m.tasks
+	dOurs, dTheirs := Diff(nil, nil, base, ours), Diff(nil, nil, base, theirs)
+	byBase := func(cs []Change) (res map[*Switch][]Change, added []Change) {
+		res = make(map[*Switch][]Change)
+		for _, c := range cs {
+			switch {
+			case c.Kind == Added:
+				added = append(added, c)
+			case c.A != nil:
+				res[c.A] = append(res[c.A], c)
+			}
+		}
+		return res, added
+	}
+	oChg, oAdd := byBase(dOurs)
+	tChg, tAdd := byBase(dTheirs)
+
+	var evs []*mergeSw
+	for _, s := range base {
+		bs := mergeSw{when: s.When(), task: taskPath(s.Task()), notes: s.Notes()}
+		eo, et := bs, bs
+		eo.apply(oChg[s])
+		et.apply(tChg[s])
+		e := &eo
+		switch {
+		case len(oChg[s]) == 0:
+			e = &et
+		case len(tChg[s]) == 0:
+		case eo.when.Equal(et.when) && eo.task == et.task && eo.removed == et.removed:
+			e.notes = mergeNotes3(bs.notes, eo.notes, et.notes)
+		case eo.removed:
+			e = &et
+			m.conflict(e.when, "merge: removed by ours")
+		default:
+			m.conflict(e.when, "merge: theirs has %s", &et)
+		}
+		if !e.removed {
+			evs = append(evs, e)
+		}
+	}
+	for _, c := range oAdd {
+		evs = append(evs, &mergeSw{when: c.B.When(), task: taskPath(c.B.Task()), notes: c.B.Notes()})
+	}
+	for _, c := range tAdd {
+		i := slices.IndexFunc(evs, func(e *mergeSw) bool { return e.when.Equal(c.B.When()) })
+		switch {
+		case i < 0:
+			evs = append(evs, &mergeSw{when: c.B.When(), task: taskPath(c.B.Task()), notes: c.B.Notes()})
+		case evs[i].task == taskPath(c.B.Task()):
+			evs[i].notes = mergeNotes(slices.Clone(evs[i].notes), c.B.Notes())
+		default:
+			m.conflict(c.B.When(), "merge: theirs switches to %s", taskPath(c.B.Task()))
+		}
+	}
+
+	oSpans, tSpans := changeSpans(dOurs), changeSpans(dTheirs)
+	for _, o := range oSpans {
+		for _, t := range tSpans {
+			switch {
+			case o.c.A != nil && o.c.A == t.c.A:
+				continue // Handled above
+			case o.c.Kind == Added && t.c.Kind == Added && o.c.B.When().Equal(t.c.B.When()):
+				continue // Handled above
+			case !o.start.Before(t.end) && !o.start.Equal(t.start):
+				continue
+			case !t.start.Before(o.end) && !o.start.Equal(t.start):
+				continue
+			}
+			at := o.start
+			if t.start.After(at) {
+				at = t.start
+			}
+			m.conflict(at, "merge: overlaps with theirs %s", t.c)
+		}
+	}
+
+	slices.SortStableFunc(evs, func(a, b *mergeSw) int { return a.when.Compare(b.when) })
+	for _, e := range evs {
+		var task *Task
+		if e.task != "stop" {
+			if m.deleted[e.task] {
+				m.conflict(e.when, "merge: switch to removed task %s", e.task)
+			}
+			task, _ = m.root.GetString(e.task)
+		}
+		if i := res.Switch(e.when, task); i >= 0 {
+			res[i].notes = mergeNotes(res[i].notes, e.notes)
+		}
+	}
+	for _, cn := range m.conflicts {
+		_, s := res.Pick(cn.at)
+		if s == nil {
+			if len(res) == 0 {
+				continue
+			}
+			s = res[0]
+		}
+		s.notes = append(s.notes, Note{Sym: ConflictSym, Text: cn.text})
+	}
+	return res
+}
@@ -0,0 +1,138 @@
+package tiktak
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func ExampleMerge3() {
+	read := func(s string) Version {
+		var root Task
+		tl, err := Read(strings.NewReader(s), &root)
+		if err != nil {
+			panic(err)
+		}
+		return Version{Root: &root, TimeLine: tl}
+	}
+	base := read(`/a Title
+2023-04-01T08:00:00Z /a
+2023-04-01T12:00:00Z`)
+	ours := read(`/a Title
+2023-04-01T08:00:00Z /a
+2023-04-01T12:00:00Z
+2023-04-01T13:00:00Z /b
+2023-04-01T14:00:00Z`)
+	theirs := read(`/a New title
+2023-04-01T08:00:00Z /a
+2023-04-01T10:00:00Z /c
+2023-04-01T12:00:00Z
+2023-04-01T13:30:00Z /d
+2023-04-01T15:00:00Z`)
+	var root Task
+	tl, n := Merge3(&root, base, ours, theirs)
+	fmt.Println("conflicts:", n)
+	WriteTree(os.Stdout, &root, tl)
+	// Output:
+	// conflicts: 2
+	// v1.4.0	tiktak time tracker
+	// /a New title
+	// /b
+	// /c
+	// /d
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T08:00:00Z /a
+	// 2023-04-01T10:00:00Z /c
+	// 2023-04-01T12:00:00Z
+	// 2023-04-01T13:00:00Z /b
+	// 2023-04-01T13:30:00Z /d
+	// 	!⚡ merge: overlaps with theirs + 2023-04-01T13:30:00Z /d
+	// 2023-04-01T14:00:00Z
+	// 	!⚡ merge: overlaps with theirs + 2023-04-01T13:30:00Z /d
+}
+
+func ExampleMerge3_removed() {
+	read := func(s string) Version {
+		var root Task
+		tl, err := Read(strings.NewReader(s), &root)
+		if err != nil {
+			panic(err)
+		}
+		return Version{Root: &root, TimeLine: tl}
+	}
+	base := read(`/old
+/gone
+	. note
+/keep
+	. first
+	. second
+2023-04-01
+	. day note
+2023-04-01T08:00:00Z /old
+2023-04-01T12:00:00Z`)
+	ours := read(`/new
+/gone
+	. note
+/keep
+	. first
+2023-04-01T08:00:00Z /new
+2023-04-01T12:00:00Z`)
+	theirs := read(`/old
+/keep
+	. first
+	. second
+	. third
+2023-04-01
+	. day note
+2023-04-01T08:00:00Z /old
+2023-04-01T12:00:00Z`)
+	var root Task
+	tl, n := Merge3(&root, base, ours, theirs)
+	fmt.Println("conflicts:", n)
+	WriteTree(os.Stdout, &root, tl)
+	// Output:
+	// conflicts: 0
+	// v1.4.0	tiktak time tracker
+	// /keep
+	// 	. first
+	// 	. third
+	// /new
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T08:00:00Z /new
+	// 2023-04-01T12:00:00Z
+}
+
+func ExampleMerge3_conflict() {
+	read := func(s string) Version {
+		var root Task
+		tl, err := Read(strings.NewReader(s), &root)
+		if err != nil {
+			panic(err)
+		}
+		return Version{Root: &root, TimeLine: tl}
+	}
+	base := read(`/a
+/a/b
+2023-04-01T08:00:00Z /a/b
+2023-04-01T12:00:00Z`)
+	ours := read(`2023-04-01T08:00:00Z /c
+2023-04-01T12:00:00Z`)
+	theirs := read(`/a
+/a/b Title
+2023-04-01T08:00:00Z /a/b
+2023-04-01T12:00:00Z`)
+	var root Task
+	tl, n := Merge3(&root, base, ours, theirs)
+	fmt.Println("conflicts:", n)
+	WriteTree(os.Stdout, &root, tl)
+	// Output:
+	// conflicts: 1
+	// v1.4.0	tiktak time tracker
+	// /a
+	// 	!⚡ merge: removed by ours
+	// /a/b Title
+	// /c
+	// # Sat, 01 Apr 2023
+	// 2023-04-01T08:00:00Z /c
+	// 2023-04-01T12:00:00Z
+}
@@ -1,11 +1,14 @@
 package tiktak
 
 import (
+	"errors"
 	"fmt"
 	"path"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type Task struct {
@@ -14,10 +17,16 @@ type Task struct {
 	subs   []*Task
 	starts []*Switch
 	title  string
+	attrs  map[string]string
+	notes  []Note
+	days   map[Date][]Note
+	tracks map[string]*TimeLine
 }
 
 func (t *Task) Name() string { return t.name }
 
+func (t *Task) Parent() *Task { return t.parent }
+
 func (t *Task) Title() string     { return t.title }
 func (t *Task) SetTitle(s string) { t.title = s }
 
@@ -44,9 +53,13 @@ func cmprName(n, m string) int {
 	return c
 }
 
+// validName rejects names with control characters and line breaks. Since
+// file version 1.4 names may contain spaces.
 func validName(n string) error {
-	if strings.ContainsAny(n, "\t\n\v\f\r \x85\xA0") {
-		return fmt.Errorf("invalid name '%s'", n)
+	if strings.ContainsFunc(n, func(r rune) bool {
+		return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+	}) {
+		return fmt.Errorf("invalid name %q", n)
 	}
 	return nil
 }
@@ -56,38 +69,54 @@ func (t *Task) Get(path ...string) (*Task, error) {
 		if err := validName(n); err != nil {
 			return nil, err
 		}
-		l := len(t.subs)
-		i := sort.Search(l, func(i int) bool { return cmprName(t.subs[i].name, n) >= 0 })
-		if i == l {
-			nt := &Task{
-				parent: t,
-				name:   n,
-			}
-			t.subs = append(t.subs, nt)
-			t = nt
-		} else if t.subs[i].name == n {
+		i, ok := t.searchSub(n)
+		if ok {
 			t = t.subs[i]
 		} else {
 			nt := &Task{
 				parent: t,
 				name:   n,
 			}
-			if l := len(t.subs); cap(t.subs) > l {
-				t.subs = t.subs[:l+1]
-				copy(t.subs[i+1:], t.subs[i:])
-			} else {
-				tmp := make([]*Task, l+1)
-				copy(tmp, t.subs[:i])
-				copy(tmp[i+1:], t.subs[i:])
-				t.subs = tmp
-			}
-			t.subs[i] = nt
+			t.insertSub(i, nt)
 			t = nt
 		}
 	}
 	return t, nil
 }
 
+func (t *Task) searchSub(n string) (int, bool) {
+	l := len(t.subs)
+	i := sort.Search(l, func(i int) bool { return cmprName(t.subs[i].name, n) >= 0 })
+	return i, i < l && t.subs[i].name == n
+}
+
+func (t *Task) insertSub(i int, st *Task) {
+	if l := len(t.subs); i == l {
+		t.subs = append(t.subs, st)
+		return
+	} else if cap(t.subs) > l {
+		t.subs = t.subs[:l+1]
+		copy(t.subs[i+1:], t.subs[i:])
+	} else {
+		tmp := make([]*Task, l+1)
+		copy(tmp, t.subs[:i])
+		copy(tmp[i+1:], t.subs[i:])
+		t.subs = tmp
+	}
+	t.subs[i] = st
+}
+
+func (t *Task) removeSub(st *Task) {
+	for i, s := range t.subs {
+		if s == st {
+			copy(t.subs[i:], t.subs[i+1:])
+			t.subs[len(t.subs)-1] = nil
+			t.subs = t.subs[:len(t.subs)-1]
+			return
+		}
+	}
+}
+
 func (t *Task) GetString(p string) (*Task, error) {
 	if p == "/" {
 		return t.Root(), nil
@@ -257,6 +286,9 @@ func (t *Task) addStart(s *Switch) {
 }
 
 func (t *Task) rmStart(s *Switch) {
+	if t == nil {
+		return
+	}
 	for i, r := range t.starts {
 		if r == s {
 			copy(t.starts[i:], t.starts[i+1:])
@@ -288,6 +320,105 @@ func (t *Task) Visit(pre bool, do func(*Task) error) error {
 	return nil
 }
 
+// Rename changes the name of t. The new name must not be used by a sibling
+// of t.
+func (t *Task) Rename(name string) error {
+	if t.parent == nil {
+		return errors.New("cannot rename root task")
+	}
+	if name == "" {
+		return errors.New("empty task name")
+	}
+	if err := validName(name); err != nil {
+		return err
+	}
+	if name == t.name {
+		return nil
+	}
+	p := t.parent
+	i, ok := p.searchSub(name)
+	if ok {
+		return fmt.Errorf("task %s already exists", p.subs[i])
+	}
+	p.removeSub(t)
+	t.name = name
+	i, _ = p.searchSub(name)
+	p.insertSub(i, t)
+	return nil
+}
+
+// MoveTo makes t a subtask of parent. Parent must not already have a subtask
+// with the name of t.
+func (t *Task) MoveTo(parent *Task) error {
+	switch {
+	case t.parent == nil:
+		return errors.New("cannot move root task")
+	case parent == t.parent:
+		return nil
+	case parent.Is(t):
+		return fmt.Errorf("cannot move task %s into its own subtree %s", t, parent)
+	case parent.Root() != t.Root():
+		return fmt.Errorf("cannot move task %s to other task tree", t)
+	}
+	i, ok := parent.searchSub(t.name)
+	if ok {
+		return fmt.Errorf("task %s already exists", parent.subs[i])
+	}
+	t.parent.removeSub(t)
+	t.parent = parent
+	parent.insertSub(i, t)
+	return nil
+}
+
+// MergeInto moves all switches of t to dst and removes t from the task tree.
+// Subtasks of t are merged into the subtasks of dst with the same name or are
+// moved to dst. If dst has no title it gets the title of t. Attributes of t
+// that dst does not have are copied to dst and the notes of t are appended
+// to those of dst. Time lines that
+// switch to t will then have consecutive switches to dst. Use
+// [TimeLine.Normalize] to clean them up.
+func (t *Task) MergeInto(dst *Task) error {
+	switch {
+	case t == dst:
+		return nil
+	case t.parent == nil:
+		return errors.New("cannot merge root task")
+	case dst.Is(t):
+		return fmt.Errorf("cannot merge task %s into its own subtree %s", t, dst)
+	case dst.Root() != t.Root():
+		return fmt.Errorf("cannot merge task %s into other task tree", t)
+	}
+	subs := slices.Clone(t.subs)
+	for _, st := range subs {
+		if i, ok := dst.searchSub(st.name); ok {
+			if err := st.MergeInto(dst.subs[i]); err != nil {
+				return err
+			}
+		} else {
+			t.removeSub(st)
+			st.parent = dst
+			dst.insertSub(i, st)
+		}
+	}
+	for _, s := range t.starts {
+		s.to = dst
+		dst.addStart(s)
+	}
+	t.starts = nil
+	if dst.title == "" {
+		dst.title = t.title
+	}
+	for k, v := range t.attrs {
+		if _, ok := dst.attrs[k]; !ok {
+			dst.SetAttr(k, v)
+		}
+	}
+	dst.notes = append(dst.notes, t.notes...)
+	t.parent.removeSub(t)
+	t.parent = nil
+	return nil
+}
+
 type Note struct {
 	Sym  rune
 	Text string
@@ -298,6 +429,7 @@ type Switch struct {
 	when  time.Time
 	next  *Switch
 	notes []Note
+	line  int
 }
 
 func (s *Switch) Task() *Task     { return s.to }
@@ -305,6 +437,9 @@ func (s *Switch) When() time.Time { return s.when }
 func (s *Switch) Next() *Switch   { return s.next }
 func (s *Switch) Notes() []Note   { return s.notes }
 
+// Line returns the line number the switch was read from or 0 if unknown.
+func (s *Switch) Line() int { return s.line }
+
 func Warning(n Note) bool { return n.Sym != 0 }
 
 func (s *Switch) SelectNotes(idxs []int, f func(Note) bool) []int {
@@ -489,6 +624,9 @@ func (tl *TimeLine) changeTask(i int, of *Switch, to *Task) int {
 
 func (tl *TimeLine) ClipBefore(t time.Time) {
 	i, rt := tl.Pick(t)
+	if rt == nil {
+		return
+	}
 	for _, s := range (*tl)[:i] {
 		s.reset()
 	}
@@ -498,6 +636,7 @@ func (tl *TimeLine) ClipBefore(t time.Time) {
 		return
 	}
 	rt.when = t
+	*tl = (*tl)[i:]
 }
 
 func (tl *TimeLine) ClipAfter(t time.Time) {
@@ -505,6 +644,11 @@ func (tl *TimeLine) ClipAfter(t time.Time) {
 	for _, s := range (*tl)[i+1:] {
 		s.reset()
 	}
+	*tl = (*tl)[:i+1]
+	if rt == nil {
+		return
+	}
+	rt.next = nil
 	if rt.Task() == nil {
 		return
 	}
@@ -521,6 +665,48 @@ func (tl *TimeLine) Clip(start, end time.Time) {
 	tl.ClipAfter(end)
 }
 
+// Append appends the time line more that must start after the end of tl. If
+// more starts with a switch to the task that is running at the end of tl,
+// the running span is continued and only the notes of the switch are kept.
+// A stop at the end of tl that happens at the start of more is dropped,
+// which continues a span that was split at the boundary.
+func (tl *TimeLine) Append(more TimeLine) error {
+	if len(more) == 0 {
+		return nil
+	}
+	if l := len(*tl); l > 0 {
+		last := (*tl)[l-1]
+		if last.Task() == nil && len(last.notes) == 0 && last.When().Equal(more[0].When()) {
+			last.reset()
+			*tl = (*tl)[:l-1]
+			if l == 1 {
+				*tl = append(*tl, more...)
+				return nil
+			}
+			last = (*tl)[l-2]
+			last.next = nil
+		}
+		if !more[0].When().After(last.When()) {
+			return fmt.Errorf("cannot append switch at %s before end of time line at %s",
+				more[0].When().Format(time.RFC3339),
+				last.When().Format(time.RFC3339),
+			)
+		}
+		if first := more[0]; first.Task() == last.Task() {
+			last.notes = append(last.notes, first.notes...)
+			if first.to != nil {
+				first.to.rmStart(first)
+			}
+			more = more[1:]
+		}
+		if len(more) > 0 {
+			last.next = more[0]
+		}
+	}
+	*tl = append(*tl, more...)
+	return nil
+}
+
 func (tl *TimeLine) Reschedule(i int, to time.Time) error {
 	sw := (*tl)[i]
 	if to.Before(sw.When()) {
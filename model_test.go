@@ -301,6 +301,63 @@ func TestTimeLine_Del(t *testing.T) {
 	)
 }
 
+func TestTimeLine_Clip(t *testing.T) {
+	var rt Task
+	t0 := test.Err(rt.Get("task0")).ShallNot(t)
+	t1 := test.Err(rt.Get("task1")).ShallNot(t)
+	now := time.Date(2023, time.April, 1, 12, 0, 0, 0, time.UTC)
+	var tl TimeLine
+	tl.Switch(now, t0)
+	tl.Switch(now.Add(time.Hour), nil)
+	tl.Switch(now.Add(2*time.Hour), t1)
+	tl.Switch(now.Add(4*time.Hour), t0)
+	tl.Clip(now.Add(30*time.Minute), now.Add(3*time.Hour))
+	expectTL(t, tl,
+		sw{now.Add(30 * time.Minute), t0},
+		sw{now.Add(time.Hour), nil},
+		sw{now.Add(2 * time.Hour), t1},
+		sw{now.Add(3 * time.Hour), nil},
+	)
+	if tl[3].Next() != nil || len(t0.starts) != 1 {
+		t.Error("clipped switches not reset")
+	}
+	tl.ClipAfter(now)
+	if len(tl) != 0 {
+		t.Errorf("clip before start leaves %d switches", len(tl))
+	}
+}
+
+func TestTimeLine_Append(t *testing.T) {
+	var rt Task
+	t0 := test.Err(rt.Get("task0")).ShallNot(t)
+	t1 := test.Err(rt.Get("task1")).ShallNot(t)
+	now := time.Date(2023, time.April, 30, 22, 0, 0, 0, time.UTC)
+	var tl, more TimeLine
+	tl.Switch(now, t0)
+	more.Switch(now.Add(2*time.Hour), t0)
+	more[0].AddNote("continued")
+	more.Switch(now.Add(3*time.Hour), t1)
+	if err := tl.Append(more); err != nil {
+		t.Fatal(err)
+	}
+	expectTL(t, tl, sw{now, t0}, sw{now.Add(3 * time.Hour), t1})
+	if tl[0].Next() != tl[1] || len(tl[0].Notes()) != 1 || len(t0.starts) != 1 {
+		t.Error("continued span not joined")
+	}
+	tl.Switch(now.Add(4*time.Hour), nil)
+	more = nil
+	more.Switch(now.Add(4*time.Hour), t1)
+	if err := tl.Append(more); err != nil {
+		t.Fatal(err)
+	}
+	expectTL(t, tl, sw{now, t0}, sw{now.Add(3 * time.Hour), t1})
+	var early TimeLine
+	early.Switch(now, t1)
+	if err := tl.Append(early); err == nil {
+		t.Error("appended switch before end of time line")
+	}
+}
+
 func ExampleTimeLine() {
 	root := new(Task)
 	var tl TimeLine
@@ -410,3 +467,64 @@ func ExampleTimeLine_Duration() {
 	// after/future: 30m0s 12:00:00 12:30:00
 	// after/after: 15m0s 12:15:00 12:30:00
 }
+
+func TestTask_Rename(t *testing.T) {
+	var root Task
+	a := test.Err(root.GetString("/a")).ShallNot(t)
+	test.Err(root.GetString("/c")).ShallNot(t)
+	if err := a.Rename("c"); err == nil {
+		t.Error("rename to existing sibling")
+	}
+	if err := a.Rename("d"); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != "/d" {
+		t.Errorf("renamed task is %s", a)
+	}
+	if s := root.Subtasks(); s[0].Name() != "c" || s[1] != a {
+		t.Errorf("subtasks not sorted: %s %s", s[0], s[1])
+	}
+}
+
+func TestTask_MoveTo(t *testing.T) {
+	var root Task
+	ab := test.Err(root.GetString("/a/b")).ShallNot(t)
+	c := test.Err(root.GetString("/c")).ShallNot(t)
+	if err := ab.Parent().MoveTo(ab); err == nil {
+		t.Error("move into own subtree")
+	}
+	if err := ab.MoveTo(c); err != nil {
+		t.Fatal(err)
+	}
+	if ab.String() != "/c/b" {
+		t.Errorf("moved task is %s", ab)
+	}
+	if f := root.FindString("/c/b"); f != ab {
+		t.Errorf("find moved task: %s", f)
+	}
+}
+
+func TestTask_MergeInto(t *testing.T) {
+	var root Task
+	var tl TimeLine
+	a := test.Err(root.GetString("/a")).ShallNot(t)
+	ax := test.Err(root.GetString("/a/x")).ShallNot(t)
+	b := test.Err(root.GetString("/b")).ShallNot(t)
+	bx := test.Err(root.GetString("/b/x")).ShallNot(t)
+	now := time.Date(2023, time.April, 1, 12, 0, 0, 0, time.UTC)
+	tl.Switch(now, a)
+	tl.Switch(now.Add(time.Hour), b)
+	tl.Switch(now.Add(2*time.Hour), ax)
+	tl.Switch(now.Add(3*time.Hour), bx)
+	if err := a.MergeInto(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Parent() != nil || ax.Parent() != nil {
+		t.Error("merged tasks still in task tree")
+	}
+	tl = tl.Normalize()
+	expectTL(t, tl, sw{now, b}, sw{now.Add(2 * time.Hour), bx})
+	if l := len(bx.starts); l != 1 {
+		t.Errorf("/b/x has %d starts", l)
+	}
+}
@@ -0,0 +1,109 @@
+package tiktak
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CheckNoteText returns an error if text cannot be written as a note, i.e.
+// if it is blank or has control characters other than tab.
+func CheckNoteText(text string) error {
+	switch {
+	case strings.TrimSpace(text) == "":
+		return errors.New("empty note")
+	case strings.ContainsFunc(text, noteCtrl):
+		return fmt.Errorf("control character in note %q", text)
+	}
+	return nil
+}
+
+func noteCtrl(r rune) bool { return r != '\t' && unicode.IsControl(r) }
+
+// noteText replaces control characters that would break the line of a note.
+func noteText(text string) string {
+	if !strings.ContainsFunc(text, noteCtrl) {
+		return text
+	}
+	return strings.Map(func(r rune) rune {
+		if noteCtrl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+func (t *Task) Notes() []Note { return t.notes }
+
+func (t *Task) AddNote(text string) (i int) {
+	i = len(t.notes)
+	t.notes = append(t.notes, Note{Text: text})
+	return i
+}
+
+func (t *Task) DelNote(i int) {
+	t.notes = delNote(t.notes, i)
+}
+
+func delNote(notes []Note, i int) []Note {
+	if len(notes) == 0 {
+		return notes
+	}
+	if i < 0 {
+		i += len(notes)
+	}
+	copy(notes[i:], notes[i+1:])
+	return notes[:len(notes)-1]
+}
+
+// Day notes are kept by the root of a task tree. This is the task tree a
+// time line is read into with [Read].
+
+func dayKey(d Date) Date { return Date{Year: d.Year, Month: d.Month, Day: d.Day} }
+
+// DayNotes returns the notes of day d.
+func (t *Task) DayNotes(d Date) []Note {
+	return t.Root().days[dayKey(d)]
+}
+
+func (t *Task) AddDayNote(d Date, text string) int {
+	return t.addDayNote(d, Note{Text: text})
+}
+
+func (t *Task) addDayNote(d Date, n Note) (i int) {
+	root, key := t.Root(), dayKey(d)
+	if root.days == nil {
+		root.days = make(map[Date][]Note)
+	}
+	i = len(root.days[key])
+	root.days[key] = append(root.days[key], n)
+	return i
+}
+
+func (t *Task) DelDayNote(d Date, i int) {
+	root, key := t.Root(), dayKey(d)
+	ns := delNote(root.days[key], i)
+	if len(ns) == 0 {
+		delete(root.days, key)
+	} else {
+		root.days[key] = ns
+	}
+}
+
+// NoteDays returns the sorted list of days that have notes.
+func (t *Task) NoteDays() []Date {
+	root := t.Root()
+	res := make([]Date, 0, len(root.days))
+	for d := range root.days {
+		res = append(res, d)
+	}
+	slices.SortFunc(res, func(a, b Date) int { return a.Compare(&b) })
+	return res
+}
+
+func (d Date) format(layout string) string {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC).Format(layout)
+}
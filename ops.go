@@ -0,0 +1,435 @@
+package tiktak
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OpKind is the kind of an [Op].
+type OpKind string
+
+const (
+	// OpAddTask adds task Task to the task tree.
+	OpAddTask OpKind = "add-task"
+	// OpDelTask removes the unused task Task from the task tree.
+	OpDelTask OpKind = "del-task"
+	// OpAdd adds the switch At to Task with NewNotes.
+	OpAdd OpKind = "add"
+	// OpDel removes the switch At to Task with OldNotes.
+	OpDel OpKind = "del"
+	// OpReschedule moves the switch At to Task to the time To.
+	OpReschedule OpKind = "reschedule"
+	// OpRetask makes the switch At to Task switch to NewTask.
+	OpRetask OpKind = "retask"
+	// OpNotes changes the notes of the switch At to Task.
+	OpNotes OpKind = "notes"
+	// OpTaskNotes changes the notes of task Task.
+	OpTaskNotes OpKind = "task-notes"
+	// OpDayNotes changes the notes of day Day.
+	OpDayNotes OpKind = "day-notes"
+	// OpTitle changes the title of task Task from Old to New.
+	OpTitle OpKind = "title"
+	// OpAttr changes the value of attribute Attr of task Task from Old to New.
+	// An empty value means the attribute is not set.
+	OpAttr OpKind = "attr"
+)
+
+// Op is a reversible change of a time line, of its tracks or of its task
+// tree. [Ops] computes the ops between two versions of a time line,
+// [TimeLine.Apply] replays them and [Invert] returns the ops that revert
+// them. Switches are identified by their time and the path of their task,
+// which is what a [Switch.ID] is made of. Empty task paths of switches are
+// stops.
+type Op struct {
+	Kind OpKind
+	// Track is the track of the switch. Empty means the main time line.
+	Track   string    `json:",omitempty"`
+	At      time.Time `json:",omitzero"`
+	To      time.Time `json:",omitzero"`
+	Task    string    `json:",omitempty"`
+	NewTask string    `json:",omitempty"`
+	// Day is the date of day notes as yyyy-mm-dd.
+	Day                string `json:",omitempty"`
+	Attr               string `json:",omitempty"`
+	Old, New           string `json:",omitempty"`
+	OldNotes, NewNotes []Note `json:",omitempty"`
+}
+
+func (op Op) String() string {
+	sw := func(at time.Time, task string) string {
+		return at.Format(IOTimeFmt) + " " + orStop(task)
+	}
+	track := ""
+	if op.Track != "" {
+		track = "@" + op.Track + " "
+	}
+	switch op.Kind {
+	case OpAddTask:
+		return "+ task " + op.Task
+	case OpDelTask:
+		return "- task " + op.Task
+	case OpAdd:
+		return "+ " + track + sw(op.At, op.Task)
+	case OpDel:
+		return "- " + track + sw(op.At, op.Task)
+	case OpReschedule:
+		return fmt.Sprintf("~ %s%s → %s", track, sw(op.At, op.Task), op.To.Format(IOTimeFmt))
+	case OpRetask:
+		return fmt.Sprintf("* %s%s → %s", track, sw(op.At, op.Task), orStop(op.NewTask))
+	case OpNotes:
+		return fmt.Sprintf("! notes at %s%s: %d → %d", track, sw(op.At, op.Task), len(op.OldNotes), len(op.NewNotes))
+	case OpTaskNotes:
+		return fmt.Sprintf("! notes of %s: %d → %d", op.Task, len(op.OldNotes), len(op.NewNotes))
+	case OpDayNotes:
+		return fmt.Sprintf("! notes of %s: %d → %d", op.Day, len(op.OldNotes), len(op.NewNotes))
+	case OpTitle:
+		return fmt.Sprintf("# title of %s: '%s' → '%s'", op.Task, op.Old, op.New)
+	case OpAttr:
+		return fmt.Sprintf("= %s of %s: '%s' → '%s'", op.Attr, op.Task, op.Old, op.New)
+	}
+	return string(op.Kind)
+}
+
+func orStop(task string) string {
+	if task == "" {
+		return "stop"
+	}
+	return task
+}
+
+// Ops returns the ops that change the task tree aRoot with time line a into
+// the task tree bRoot with time line b. The tracks of the roots are compared
+// too. A nil root is an empty task tree. Applying the ops in order adds tasks
+// first and removes unused tasks last.
+func Ops(aRoot, bRoot *Task, a, b TimeLine) (ops []Op) {
+	var noneA, noneB Task
+	if aRoot == nil {
+		aRoot = &noneA
+	}
+	if bRoot == nil {
+		bRoot = &noneB
+	}
+	bRoot.Visit(true, func(t *Task) error {
+		if t.parent != nil && aRoot.Find(false, t.Path()...) == nil {
+			ops = append(ops, Op{Kind: OpAddTask, Task: t.String()})
+		}
+		return nil
+	})
+	ops = appendSwitchOps(ops, "", Diff(nil, nil, a, b))
+	tracks := make(map[string]bool)
+	for _, n := range aRoot.Tracks() {
+		tracks[n] = true
+	}
+	for _, n := range bRoot.Tracks() {
+		tracks[n] = true
+	}
+	for _, n := range slices.Sorted(maps.Keys(tracks)) {
+		var ta, tb TimeLine
+		if tl := aRoot.Track(n); tl != nil {
+			ta = *tl
+		}
+		if tl := bRoot.Track(n); tl != nil {
+			tb = *tl
+		}
+		ops = appendSwitchOps(ops, n, Diff(nil, nil, ta, tb))
+	}
+	for _, c := range diffTasks(aRoot, bRoot) {
+		switch {
+		case c.Kind == TitleChanged:
+			ops = append(ops, Op{Kind: OpTitle, Task: c.Task, Old: c.OldTitle, New: c.NewTitle})
+		case c.Kind == AttrChanged:
+			ops = append(ops, Op{Kind: OpAttr, Task: c.Task, Attr: c.Attr, Old: c.OldValue, New: c.NewValue})
+		case c.Day != nil:
+			ops = append(ops, Op{
+				Kind:     OpDayNotes,
+				Day:      c.Day.format(dayNoteFmt),
+				OldNotes: c.OldNotes,
+				NewNotes: c.NewNotes,
+			})
+		default:
+			ops = append(ops, Op{Kind: OpTaskNotes, Task: c.Task, OldNotes: c.OldNotes, NewNotes: c.NewNotes})
+		}
+	}
+	aRoot.Visit(false, func(t *Task) error {
+		if t.parent != nil && bRoot.Find(false, t.Path()...) == nil {
+			ops = append(ops, Op{Kind: OpDelTask, Task: t.String()})
+		}
+		return nil
+	})
+	return ops
+}
+
+func appendSwitchOps(ops []Op, track string, cs []Change) []Op {
+	for _, c := range cs {
+		switch c.Kind {
+		case Added:
+			ops = append(ops, Op{
+				Kind:     OpAdd,
+				Track:    track,
+				At:       c.B.When(),
+				Task:     switchTask(c.B),
+				NewNotes: c.B.Notes(),
+			})
+		case Removed:
+			ops = append(ops, Op{
+				Kind:     OpDel,
+				Track:    track,
+				At:       c.A.When(),
+				Task:     switchTask(c.A),
+				OldNotes: c.A.Notes(),
+			})
+		case Rescheduled:
+			ops = append(ops, Op{
+				Kind:  OpReschedule,
+				Track: track,
+				At:    c.A.When(),
+				To:    c.B.When(),
+				Task:  switchTask(c.A),
+			})
+		case Retasked:
+			ops = append(ops, Op{
+				Kind:    OpRetask,
+				Track:   track,
+				At:      c.A.When(),
+				Task:    switchTask(c.A),
+				NewTask: switchTask(c.B),
+			})
+		case NotesChanged:
+			ops = append(ops, Op{
+				Kind:     OpNotes,
+				Track:    track,
+				At:       c.B.When(),
+				Task:     switchTask(c.B),
+				OldNotes: c.OldNotes,
+				NewNotes: c.NewNotes,
+			})
+		}
+	}
+	return ops
+}
+
+func switchTask(s *Switch) string {
+	if t := s.Task(); t != nil {
+		return t.String()
+	}
+	return ""
+}
+
+// Invert returns the ops that revert ops.
+func Invert(ops []Op) []Op {
+	res := make([]Op, len(ops))
+	for i, op := range ops {
+		inv := op
+		switch op.Kind {
+		case OpAddTask:
+			inv.Kind = OpDelTask
+		case OpDelTask:
+			inv.Kind = OpAddTask
+		case OpAdd:
+			inv.Kind = OpDel
+		case OpDel:
+			inv.Kind = OpAdd
+		case OpReschedule:
+			inv.At, inv.To = op.To, op.At
+		case OpRetask:
+			inv.Task, inv.NewTask = op.NewTask, op.Task
+		}
+		inv.Old, inv.New = op.New, op.Old
+		inv.OldNotes, inv.NewNotes = op.NewNotes, op.OldNotes
+		res[len(ops)-1-i] = inv
+	}
+	return res
+}
+
+// Apply applies ops to tl and its task tree root. Apply fails if an op does
+// not fit, e.g. because the switch or the task it changes is not as expected.
+// Then tl and root are left in an undefined state.
+func (tl *TimeLine) Apply(root *Task, ops []Op) error {
+	changed := make(map[string]bool)
+	line := func(track string) (*TimeLine, error) {
+		changed[track] = true
+		if track == "" {
+			return tl, nil
+		}
+		return root.MakeTrack(track)
+	}
+	for _, op := range ops {
+		var err error
+		switch op.Kind {
+		case OpAddTask, OpDelTask, OpTaskNotes, OpDayNotes, OpTitle, OpAttr:
+			err = applyTaskOp(root, op)
+		default:
+			var ltl *TimeLine
+			if ltl, err = line(op.Track); err == nil {
+				err = ltl.applySwitchOp(root, op)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	for track := range changed {
+		ltl := tl
+		if track != "" {
+			ltl = root.Track(track)
+		}
+		slices.SortStableFunc(*ltl, func(a, b *Switch) int { return a.When().Compare(b.When()) })
+		*ltl = ltl.Normalize()
+		if track != "" && len(*ltl) == 0 {
+			root.DelTrack(track)
+		}
+	}
+	return nil
+}
+
+// findPath returns the task with path p in the task tree of root.
+func findPath(root *Task, p string) *Task {
+	root = root.Root()
+	if p == "/" {
+		return root
+	}
+	return root.Find(false, strings.Split(strings.TrimPrefix(p, "/"), "/")...)
+}
+
+// opTask returns the task of a switch op. An empty path is a stop.
+func opTask(root *Task, p string) (*Task, error) {
+	if p == "" {
+		return nil, nil
+	}
+	return root.GetString(p)
+}
+
+// indexOf returns the index of the switch at to task in the unordered
+// switches of tl.
+func (tl TimeLine) indexOf(at time.Time, task string) int {
+	return slices.IndexFunc(tl, func(s *Switch) bool {
+		return s.When().Equal(at) && switchTask(s) == task
+	})
+}
+
+func (tl TimeLine) hasSwitchAt(t time.Time) bool {
+	return slices.ContainsFunc(tl, func(s *Switch) bool { return s.When().Equal(t) })
+}
+
+func (tl *TimeLine) applySwitchOp(root *Task, op Op) error {
+	if op.Kind == OpAdd {
+		if tl.hasSwitchAt(op.At) {
+			return errors.New("switch exists at that time")
+		}
+		task, err := opTask(root, op.Task)
+		if err != nil {
+			return err
+		}
+		s := &Switch{to: task, when: op.At, notes: slices.Clone(op.NewNotes)}
+		*tl = append(*tl, s)
+		task.addStart(s)
+		return nil
+	}
+	i := tl.indexOf(op.At, op.Task)
+	if i < 0 {
+		return errors.New("no such switch")
+	}
+	s := (*tl)[i]
+	switch op.Kind {
+	case OpDel:
+		if !slices.Equal(s.notes, op.OldNotes) {
+			return errors.New("notes were changed")
+		}
+		s.to.rmStart(s)
+		*tl = slices.Delete(*tl, i, i+1)
+	case OpReschedule:
+		if tl.hasSwitchAt(op.To) {
+			return errors.New("switch exists at new time")
+		}
+		s.to.rmStart(s)
+		s.when = op.To
+		s.to.addStart(s)
+	case OpRetask:
+		task, err := opTask(root, op.NewTask)
+		if err != nil {
+			return err
+		}
+		s.to.rmStart(s)
+		s.to = task
+		task.addStart(s)
+	case OpNotes:
+		if !slices.Equal(s.notes, op.OldNotes) {
+			return errors.New("notes were changed")
+		}
+		s.notes = slices.Clone(op.NewNotes)
+	default:
+		return fmt.Errorf("unknown op '%s'", op.Kind)
+	}
+	return nil
+}
+
+func applyTaskOp(root *Task, op Op) error {
+	if op.Kind == OpDayNotes {
+		d, err := time.Parse(dayNoteFmt, op.Day)
+		if err != nil {
+			return err
+		}
+		day := dayKey(DateOf(d))
+		root = root.Root()
+		if !slices.Equal(root.days[day], op.OldNotes) {
+			return errors.New("day notes were changed")
+		}
+		if len(op.NewNotes) == 0 {
+			delete(root.days, day)
+		} else {
+			if root.days == nil {
+				root.days = make(map[Date][]Note)
+			}
+			root.days[day] = slices.Clone(op.NewNotes)
+		}
+		return nil
+	}
+	t := findPath(root, op.Task)
+	if op.Kind == OpAddTask {
+		if t != nil {
+			return errors.New("task exists")
+		}
+		_, err := root.GetString(op.Task)
+		return err
+	}
+	if t == nil {
+		return errors.New("no such task")
+	}
+	switch op.Kind {
+	case OpDelTask:
+		if len(t.starts) > 0 || len(t.subs) > 0 || t.title != "" || len(t.attrs) > 0 || len(t.notes) > 0 {
+			return errors.New("task is in use")
+		}
+		if t.parent == nil {
+			return errors.New("cannot remove root task")
+		}
+		t.parent.removeSub(t)
+		t.parent = nil
+	case OpTaskNotes:
+		if !slices.Equal(t.notes, op.OldNotes) {
+			return errors.New("task notes were changed")
+		}
+		t.notes = slices.Clone(op.NewNotes)
+	case OpTitle:
+		if t.title != op.Old {
+			return errors.New("title was changed")
+		}
+		t.title = op.New
+	case OpAttr:
+		if v, _ := t.Attr(op.Attr); v != op.Old {
+			return errors.New("attribute was changed")
+		}
+		if op.New == "" {
+			t.DelAttr(op.Attr)
+			return nil
+		}
+		return t.SetAttr(op.Attr, op.New)
+	default:
+		return fmt.Errorf("unknown op '%s'", op.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package tiktak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleOps() {
+	var ra, rb Task
+	a, _ := Read(strings.NewReader(`2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00Z /b
+2023-04-01T10:00:00Z`), &ra)
+	b, _ := Read(strings.NewReader(`2023-04-01T08:00:00Z /a
+2023-04-01T09:30:00Z /b
+2023-04-01T10:00:00Z /c
+	. Note
+2023-04-01T11:00:00Z`), &rb)
+	for _, op := range Ops(&ra, &rb, a, b) {
+		fmt.Println(op)
+	}
+	// Output:
+	// + task /c
+	// ~ 2023-04-01T09:00:00Z /b → 2023-04-01T09:30:00Z
+	// * 2023-04-01T10:00:00Z stop → /c
+	// ! notes at 2023-04-01T10:00:00Z /c: 0 → 1
+	// + 2023-04-01T11:00:00Z stop
+}
+
+func TestOps_apply(t *testing.T) {
+	for _, c := range []struct{ name, a, b string }{
+		{"empty", "", `2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00Z`},
+		{"switches", `2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00Z /b
+	. Note
+2023-04-01T10:00:00Z /a
+2023-04-01T12:00:00Z`, `2023-04-01T08:00:00Z /a
+2023-04-01T09:30:00Z /b
+	. Other note
+2023-04-01T10:00:00Z /c/d
+2023-04-01T11:00:00Z /a
+2023-04-01T13:00:00Z`},
+		{"tasks", `/a [rate=95] Title
+	. Task note
+/b
+2023-04-01
+	. Day note
+2023-04-01T08:00:00Z /b
+2023-04-01T09:00:00Z`, `/b [billable=true] Other title
+/x/y
+2023-04-02
+	. Day note
+2023-04-01T08:00:00Z /x/y
+2023-04-01T09:00:00Z`},
+		{"tracks", `2023-04-01T08:00:00Z /a
+@oncall 2023-04-01T20:00:00Z /o
+@oncall 2023-04-01T22:00:00Z
+2023-04-01T09:00:00Z`, `2023-04-01T08:00:00Z /a
+2023-04-01T09:00:00Z
+@standby 2023-04-01T18:00:00Z /o
+@standby 2023-04-01T19:00:00Z`},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			read := func(s string) (*Task, TimeLine, string) {
+				root := new(Task)
+				tl, err := Read(strings.NewReader(s), root)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var buf bytes.Buffer
+				WriteTree(&buf, root, tl)
+				return root, tl, buf.String()
+			}
+			ra, a, sa := read(c.a)
+			rb, b, sb := read(c.b)
+			ops := Ops(ra, rb, a, b)
+			// Ops must survive the journal
+			data, err := json.Marshal(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops = nil
+			if err := json.Unmarshal(data, &ops); err != nil {
+				t.Fatal(err)
+			}
+			root, tl, _ := read(c.a)
+			if err := tl.Apply(root, ops); err != nil {
+				t.Fatal("apply:", err)
+			}
+			var buf bytes.Buffer
+			WriteTree(&buf, root, tl)
+			if s := buf.String(); s != sb {
+				t.Errorf("applied:\n%s\nwant:\n%s", s, sb)
+			}
+			if err := tl.Apply(root, Invert(ops)); err != nil {
+				t.Fatal("revert:", err)
+			}
+			buf.Reset()
+			WriteTree(&buf, root, tl)
+			if s := buf.String(); s != sa {
+				t.Errorf("reverted:\n%s\nwant:\n%s", s, sa)
+			}
+			if err := tl.Apply(root, Invert(ops)); err == nil && len(ops) > 0 {
+				t.Error("reverted twice")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package tiktak
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// Periods are [Buckets] of calendar periods that have a label, e.g. days,
+// quarters or pay periods. All bucket types in this package except
+// [TotalBucket] are Periods.
+type Periods interface {
+	Buckets
+	// Label returns the label of the period that starts at start.
+	Label(start time.Time) string
+}
+
+// Period is a single period Start–End of its Periods.
+type Period struct {
+	Start, End time.Time
+	Of         Periods
+}
+
+// PeriodOf returns the period of ps that contains t.
+func PeriodOf(ps Periods, t time.Time) Period {
+	s, e := ps.Bucket(t)
+	return Period{Start: s, End: e, Of: ps}
+}
+
+// PeriodsIn iterates over the periods of ps that overlap from–to.
+func PeriodsIn(ps Periods, from, to time.Time) iter.Seq[Period] {
+	return func(yield func(Period) bool) {
+		for p := PeriodOf(ps, from); p.Start.Before(to); p = p.Next() {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+func (p Period) Next() Period { return PeriodOf(p.Of, p.End) }
+
+func (p Period) Prev() Period { return PeriodOf(p.Of, p.Start.Add(-1)) }
+
+func (p Period) Label() string { return p.Of.Label(p.Start) }
+
+// Contains reports whether t is in the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+func (b DayBuckets) Label(start time.Time) string {
+	start, _ = inLoc(start, b.Location)
+	return start.Format("2006-01-02")
+}
+
+func (b WeekBuckets) Label(start time.Time) string {
+	y, w := b.Numbering().Week(start, b.Location)
+	return fmt.Sprintf("%04d-W%02d", y, w)
+}
+
+func (b WeekBuckets) Numbering() WeekNumbering {
+	if b.MinDays == 0 {
+		return WeekNumbering{Start: b.Start, MinDays: 4}
+	}
+	return WeekNumbering{Start: b.Start, MinDays: b.MinDays}
+}
+
+func (b MonthBuckets) Label(start time.Time) string {
+	start, _ = inLoc(start, b.Location)
+	return start.Format("2006-01")
+}
+
+type QuarterBuckets struct{ Location *time.Location }
+
+func (b QuarterBuckets) Bucket(t time.Time) (start, end time.Time) {
+	t, loc := inLoc(t, b.Location)
+	y, m, _ := t.Date()
+	m = (m-1)/3*3 + 1
+	return time.Date(y, m, 1, 0, 0, 0, 0, loc), time.Date(y, m+3, 1, 0, 0, 0, 0, loc)
+}
+
+func (b QuarterBuckets) Label(start time.Time) string {
+	start, _ = inLoc(start, b.Location)
+	return fmt.Sprintf("%04d-Q%d", start.Year(), (start.Month()-1)/3+1)
+}
+
+// YearBuckets are calendar years or fiscal years that begin with month
+// Start. A zero Start means January.
+type YearBuckets struct {
+	Start    time.Month
+	Location *time.Location
+}
+
+func (b YearBuckets) Bucket(t time.Time) (start, end time.Time) {
+	t, loc := inLoc(t, b.Location)
+	sm := max(b.Start, time.January)
+	y, m, _ := t.Date()
+	if m < sm {
+		y--
+	}
+	return time.Date(y, sm, 1, 0, 0, 0, 0, loc), time.Date(y+1, sm, 1, 0, 0, 0, 0, loc)
+}
+
+// Label returns the year, e.g. 2023, or both years of a fiscal year, e.g.
+// 2023/24.
+func (b YearBuckets) Label(start time.Time) string {
+	start, _ = inLoc(start, b.Location)
+	y := start.Year()
+	if b.Start <= time.January {
+		return fmt.Sprintf("%04d", y)
+	}
+	return fmt.Sprintf("%04d/%02d", y, (y+1)%100)
+}
+
+// CycleBuckets are cycles of Weeks weeks, e.g. bi-weekly pay periods. One
+// cycle starts on the day of Anchor.
+type CycleBuckets struct {
+	Weeks    int
+	Anchor   time.Time
+	Location *time.Location
+}
+
+func (b CycleBuckets) Bucket(t time.Time) (start, end time.Time) {
+	days := 7 * max(b.Weeks, 1)
+	n := dayNumber(t, b.Location) - dayNumber(b.Anchor, b.Location)
+	c := n / days
+	if n%days < 0 {
+		c--
+	}
+	anchor := StartDay(b.Anchor, 0, b.Location)
+	return StartDay(anchor, c*days, b.Location), StartDay(anchor, (c+1)*days, b.Location)
+}
+
+func (b CycleBuckets) Label(start time.Time) string {
+	start, _ = inLoc(start, b.Location)
+	return start.Format("2006-01-02")
+}
+
+// dayNumber returns the number of calendar days of t in loc since the epoch.
+func dayNumber(t time.Time, loc *time.Location) int {
+	t, _ = inLoc(t, loc)
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 3600))
+}
@@ -0,0 +1,65 @@
+package tiktak
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExamplePeriodsIn() {
+	from := time.Date(2023, time.November, 20, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC)
+	for _, ps := range []Periods{
+		QuarterBuckets{Location: time.UTC},
+		YearBuckets{Start: time.October, Location: time.UTC},
+		CycleBuckets{
+			Weeks:    4,
+			Anchor:   time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+			Location: time.UTC,
+		},
+	} {
+		for p := range PeriodsIn(ps, from, to) {
+			fmt.Printf("%s: %s – %s\n", p.Label(), p.Start.Format(time.DateOnly), p.End.Format(time.DateOnly))
+		}
+	}
+	// Output:
+	// 2023-Q4: 2023-10-01 – 2024-01-01
+	// 2024-Q1: 2024-01-01 – 2024-04-01
+	// 2023/24: 2023-10-01 – 2024-10-01
+	// 2023-11-13: 2023-11-13 – 2023-12-11
+	// 2023-12-11: 2023-12-11 – 2024-01-08
+	// 2024-01-08: 2024-01-08 – 2024-02-05
+	// 2024-02-05: 2024-02-05 – 2024-03-04
+}
+
+func TestPeriod_NextPrev(t *testing.T) {
+	loc := time.FixedZone("test", -90*60)
+	at := time.Date(2024, time.February, 29, 23, 0, 0, 0, time.UTC)
+	for _, ps := range []Periods{
+		DayBuckets{Location: loc},
+		WeekBuckets{Start: time.Sunday, Location: loc},
+		MonthBuckets{Location: loc},
+		QuarterBuckets{Location: loc},
+		YearBuckets{Location: loc},
+		YearBuckets{Start: time.April, Location: loc},
+		CycleBuckets{Weeks: 2, Anchor: time.Date(2031, 1, 1, 0, 0, 0, 0, loc), Location: loc},
+	} {
+		p := PeriodOf(ps, at)
+		if !p.Contains(at) {
+			t.Errorf("%T: %s does not contain %s", ps, p.Label(), at)
+		}
+		if p.Start.Location() != loc {
+			t.Errorf("%T: start not in location: %s", ps, p.Start)
+		}
+		n := p.Next()
+		if !n.Start.Equal(p.End) {
+			t.Errorf("%T: next %s does not start at end %s", ps, n.Start, p.End)
+		}
+		if r := n.Prev(); r != p {
+			t.Errorf("%T: prev of next %s is %s", ps, p.Label(), r.Label())
+		}
+		if n.Label() == p.Label() {
+			t.Errorf("%T: next has same label %s", ps, p.Label())
+		}
+	}
+}
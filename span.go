@@ -0,0 +1,105 @@
+package tiktak
+
+import (
+	"iter"
+	"time"
+)
+
+// Span is the time a task runs from one switch to the next. A span of the nil
+// task is a break.
+type Span struct {
+	Task       *Task
+	Start, End time.Time
+	// Open is true if the span is still running, i.e. its switch has no next
+	// switch. Then End is the time 'now' given to the iterator or zero if no
+	// 'now' was given.
+	Open   bool
+	Notes  []Note
+	Switch *Switch
+}
+
+// Duration returns the length of the span. Open spans without end, i.e. if
+// no 'now' was given, have zero duration; use Open to tell them apart.
+func (s Span) Duration() time.Duration {
+	if s.End.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// Span returns the unclipped span started by switch s.
+func (s *Switch) Span(now time.Time) Span {
+	res := Span{
+		Task:   s.to,
+		Start:  s.when,
+		Notes:  s.notes,
+		Switch: s,
+	}
+	if s.next == nil {
+		res.Open = true
+		res.End = now
+	} else {
+		res.End = s.next.when
+	}
+	return res
+}
+
+// Spans iterates over all spans of tl that intersect the range from–to. The
+// spans are clipped to the range. A zero from or to leaves the range open on
+// that side. The final switch of a stopped time line does not start a span.
+func (tl TimeLine) Spans(from, to, now time.Time) iter.Seq[Span] {
+	return func(yield func(Span) bool) {
+		for _, s := range tl.IndexedSpans(from, to, now) {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// IndexedSpans is like [TimeLine.Spans] but also yields the index of the
+// switch that starts the span.
+func (tl TimeLine) IndexedSpans(from, to, now time.Time) iter.Seq2[int, Span] {
+	return func(yield func(int, Span) bool) {
+		i := 0
+		if !from.IsZero() {
+			if i, _ = tl.Pick(from); i < 0 {
+				i = 0
+			}
+		}
+		for ; i < len(tl); i++ {
+			sw := tl[i]
+			if !to.IsZero() && !sw.When().Before(to) {
+				return
+			}
+			if sw.Task() == nil && sw.Next() == nil {
+				return
+			}
+			span := sw.Span(now)
+			if !span.End.IsZero() && !from.IsZero() && !span.End.After(from) {
+				continue
+			}
+			if !from.IsZero() && span.Start.Before(from) {
+				span.Start = from
+			}
+			if !to.IsZero() && (span.End.IsZero() || to.Before(span.End)) {
+				span.End = to
+			}
+			if !yield(i, span) {
+				return
+			}
+		}
+	}
+}
+
+// TaskSpans iterates over the spans from [TimeLine.Spans] whose switch is
+// selected by f.
+func (tl TimeLine) TaskSpans(from, to, now time.Time, f func(*Switch) bool) iter.Seq[Span] {
+	return func(yield func(Span) bool) {
+		for s := range tl.Spans(from, to, now) {
+			if f(s.Switch) && !yield(s) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package tiktak
+
+import (
+	"fmt"
+	"time"
+
+	"git.fractalqb.de/fractalqb/catch"
+)
+
+func ExampleTimeLine_Spans() {
+	root := new(Task)
+	var tl TimeLine
+	t := time.Date(2023, time.April, 1, 12, 0, 0, 0, time.UTC)
+	tl.Switch(t, catch.MustRet(root.Get("1")))
+	tl.Switch(t.Add(time.Hour), nil)
+	tl.Switch(t.Add(90*time.Minute), catch.MustRet(root.Get("2")))
+	show := func(from, to, now time.Time) {
+		for s := range tl.Spans(from, to, now) {
+			fmt.Println(s.Task, s.Start.Format(time.TimeOnly), s.End.Format(time.TimeOnly), s.Duration(), s.Open)
+		}
+		fmt.Println("--")
+	}
+	show(time.Time{}, time.Time{}, t.Add(2*time.Hour))
+	show(t.Add(30*time.Minute), t.Add(100*time.Minute), t.Add(2*time.Hour))
+	show(t.Add(time.Hour), time.Time{}, time.Time{})
+	// Output:
+	// /1 12:00:00 13:00:00 1h0m0s false
+	// - 13:00:00 13:30:00 30m0s false
+	// /2 13:30:00 14:00:00 30m0s true
+	// --
+	// /1 12:30:00 13:00:00 30m0s false
+	// - 13:00:00 13:30:00 30m0s false
+	// /2 13:30:00 13:40:00 10m0s true
+	// --
+	// - 13:00:00 13:30:00 30m0s false
+	// /2 13:30:00 00:00:00 0s true
+	// --
+}
@@ -0,0 +1,73 @@
+package tiktak
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const idTaskMod = 36 * 36
+
+// ID returns an identifier of the switch that is derived from its time and
+// its task. The ID does not depend on the switch's position in the time line.
+// It stays valid until the switch itself is rescheduled or gets another task.
+// The time is encoded unsigned so that times before 1970 do not add a second
+// '-' to the ID.
+func (s *Switch) ID() string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(strconv.FormatUint(uint64(s.when.Unix()), 36)))
+	sb.WriteByte('-')
+	th := strings.ToUpper(strconv.FormatUint(uint64(idTaskHash(s.to)), 36))
+	if len(th) < 2 {
+		sb.WriteByte('0')
+	}
+	sb.WriteString(th)
+	return sb.String()
+}
+
+func idTaskHash(t *Task) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(t.String()))
+	return h.Sum32() % idTaskMod
+}
+
+func parseSwitchID(id string) (unix int64, task uint32, err error) {
+	ts, th, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid switch ID '%s'", id)
+	}
+	u, err := strconv.ParseUint(ts, 36, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("switch ID '%s' time: %w", id, err)
+	}
+	unix = int64(u)
+	h, err := strconv.ParseUint(th, 36, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("switch ID '%s' task: %w", id, err)
+	}
+	return unix, uint32(h), nil
+}
+
+// FindID returns the index of the switch with the [Switch.ID] id.
+func (tl TimeLine) FindID(id string) (int, error) {
+	unix, th, err := parseSwitchID(id)
+	if err != nil {
+		return -1, err
+	}
+	i, _ := tl.Pick(time.Unix(unix+1, 0).Add(-1))
+	var found []int
+	for ; i >= 0 && tl[i].When().Unix() == unix; i-- {
+		if idTaskHash(tl[i].Task()) == th {
+			found = append(found, i)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return -1, fmt.Errorf("no switch with ID '%s'", id)
+	case 1:
+		return found[0], nil
+	}
+	return -1, fmt.Errorf("ambiguous switch ID '%s'", id)
+}
@@ -0,0 +1,45 @@
+package tiktak
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/catch"
+)
+
+func ExampleTimeLine_FindID() {
+	root := new(Task)
+	var tl TimeLine
+	t := time.Date(2023, time.April, 1, 12, 0, 0, 0, time.UTC)
+	tl.Switch(t, catch.MustRet(root.Get("1")))
+	tl.Switch(t.Add(time.Hour), catch.MustRet(root.Get("2")))
+	id := tl[1].ID()
+	fmt.Println(id)
+	tl.Switch(t.Add(-time.Hour), catch.MustRet(root.Get("3")))
+	fmt.Println(tl.FindID(id))
+	tl.Switch(t.Add(time.Hour), catch.MustRet(root.Get("4")))
+	fmt.Println(tl.FindID(id))
+	// Output:
+	// RSFTG0-44
+	// 2 <nil>
+	// -1 no switch with ID 'RSFTG0-44'
+}
+
+func TestSwitchID_before1970(t *testing.T) {
+	root := new(Task)
+	var tl TimeLine
+	at := time.Date(1969, time.July, 20, 20, 17, 0, 0, time.UTC)
+	tl.Switch(at, catch.MustRet(root.Get("moon")))
+	tl.Switch(at.Add(time.Hour), nil)
+	for i, s := range tl {
+		id := s.ID()
+		if strings.Count(id, "-") != 1 {
+			t.Errorf("ID %s has not exactly one '-'", id)
+		}
+		if j, err := tl.FindID(id); err != nil || j != i {
+			t.Errorf("find %s: %d %v", id, j, err)
+		}
+	}
+}
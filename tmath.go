@@ -5,10 +5,21 @@ import (
 	"time"
 )
 
-func AddDay(t time.Time, add int, loc *time.Location) time.Time {
+// The functions in this file compute calendar dates and wall clock times in
+// location loc. If loc is nil, the location of the time argument is used.
+// Times are converted to loc before their date is taken. As days, weeks and
+// months are computed on the wall clock they do not always have 24 hours per
+// day, e.g. on DST changes.
+
+func inLoc(t time.Time, loc *time.Location) (time.Time, *time.Location) {
 	if loc == nil {
-		loc = t.Location()
+		return t, t.Location()
 	}
+	return t.In(loc), loc
+}
+
+func AddDay(t time.Time, add int, loc *time.Location) time.Time {
+	t, loc = inLoc(t, loc)
 	y, m, d := t.Date()
 	ch, cm, cs := t.Clock()
 	nano := t.Nanosecond()
@@ -16,15 +27,14 @@ func AddDay(t time.Time, add int, loc *time.Location) time.Time {
 }
 
 func StartDay(t time.Time, add int, loc *time.Location) time.Time {
-	if loc == nil {
-		loc = t.Location()
-	}
+	t, loc = inLoc(t, loc)
 	y, m, d := t.Date()
 	return time.Date(y, m, d+add, 0, 0, 0, 0, loc)
 }
 
 // LastDay returns Time from shifted to the last Weekday wd not after from.
 func LastDay(wd time.Weekday, from time.Time, loc *time.Location) time.Time {
+	from, loc = inLoc(from, loc)
 	dd := int(wd - from.Weekday())
 	if dd > 0 {
 		dd -= 7
@@ -34,6 +44,7 @@ func LastDay(wd time.Weekday, from time.Time, loc *time.Location) time.Time {
 
 // NextDay returns Time from shifted to the next Weekday wd after from.
 func NextDay(wd time.Weekday, from time.Time, loc *time.Location) time.Time {
+	from, loc = inLoc(from, loc)
 	dd := int(wd - from.Weekday())
 	if dd <= 0 {
 		dd += 7
@@ -41,10 +52,32 @@ func NextDay(wd time.Weekday, from time.Time, loc *time.Location) time.Time {
 	return AddDay(from, dd, loc)
 }
 
+// WeekNumbering numbers weeks that start on weekday Start. Week 1 of a year
+// is the first week with at least MinDays days in that year. Weeks before
+// week 1 belong to the previous year.
+type WeekNumbering struct {
+	Start   time.Weekday
+	MinDays int
+}
+
+var (
+	// ISOWeeks are the week numbers of ISO 8601, see [time.Time.ISOWeek].
+	ISOWeeks = WeekNumbering{Start: time.Monday, MinDays: 4}
+	// USWeeks start on Sunday and week 1 contains January 1st.
+	USWeeks = WeekNumbering{Start: time.Sunday, MinDays: 1}
+)
+
+// Week returns the year and the number of the week that contains t in loc.
+func (wn WeekNumbering) Week(t time.Time, loc *time.Location) (year, week int) {
+	start := LastDay(wn.Start, StartDay(t, 0, loc), loc)
+	minDays := max(1, min(wn.MinDays, 7))
+	// The day of the week that decides the year of the week
+	d := AddDay(start, 7-minDays, loc)
+	return d.Year(), (d.YearDay()-1)/7 + 1
+}
+
 func StartMonth(t time.Time, add int, loc *time.Location) time.Time {
-	if loc == nil {
-		loc = t.Location()
-	}
+	t, loc = inLoc(t, loc)
 	y, m, _ := t.Date()
 	return time.Date(y, m+time.Month(add), 1, 0, 0, 0, 0, loc)
 }
@@ -67,6 +100,12 @@ type Date struct {
 	Location *time.Location
 }
 
+// DateIn returns the date of t in location loc.
+func DateIn(t time.Time, loc *time.Location) Date {
+	t, _ = inLoc(t, loc)
+	return DateOf(t)
+}
+
 func DateOf(t time.Time) (res Date) {
 	res.Year, res.Month, res.Day = t.Date()
 	res.Location = t.Location()
@@ -87,22 +126,30 @@ func (d *Date) Start() time.Time {
 	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, d.Location)
 }
 
+// Clock is a wall clock time in Location. Dur is the time since midnight as
+// shown by a clock, which differs from the elapsed time on DST changes.
 type Clock struct {
 	Dur      time.Duration
 	Location *time.Location
 }
 
 func ClockOf(t time.Time) Clock {
-	s := StartDay(t, 0, nil)
+	h, m, s := t.Clock()
 	return Clock{
-		Dur:      t.Sub(s),
+		Dur: time.Duration(h)*time.Hour +
+			time.Duration(m)*time.Minute +
+			time.Duration(s)*time.Second +
+			time.Duration(t.Nanosecond()),
 		Location: t.Location(),
 	}
 }
 
+// On returns the time when the wall clock of c's location shows c on the date
+// of day in c's location. If c has no location, day's location is used.
 func (c Clock) On(day time.Time) time.Time {
-	s := StartDay(day, 0, day.Location())
-	return s.Add(c.Dur) // TODO c.Location ?
+	day, loc := inLoc(day, c.Location)
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, int(c.Dur), loc)
 }
 
 func (c Clock) HMSF() (h, m, s int, f float64) { return HMSF(c.Dur) }
@@ -2,6 +2,7 @@ package tiktak
 
 import (
 	"fmt"
+	"testing"
 	"time"
 )
 
@@ -61,3 +62,49 @@ func ExampleStartMonth() {
 	// 1999-05-01 00:00:00 +0000 UTC
 	// 1999-07-01 00:00:00 +0000 UTC
 }
+
+func ExampleStartDay_dst() {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	t := time.Date(2023, time.March, 26, 1, 30, 0, 0, time.UTC) // 03:30 CEST
+	s, e := StartDay(t, 0, berlin), StartDay(t, 1, berlin)
+	fmt.Println(s, e, e.Sub(s))
+	fmt.Println(StartMonth(time.Date(2023, time.March, 31, 23, 0, 0, 0, time.UTC), 0, berlin))
+	c := ClockOf(t.In(berlin))
+	fmt.Println(c.Dur, c.On(time.Date(2023, time.March, 27, 0, 0, 0, 0, berlin)))
+	// Output:
+	// 2023-03-26 00:00:00 +0100 CET 2023-03-27 00:00:00 +0200 CEST 23h0m0s
+	// 2023-04-01 00:00:00 +0200 CEST
+	// 3h30m0s 2023-03-27 03:30:00 +0200 CEST
+}
+
+func ExampleWeekNumbering() {
+	for _, t := range []time.Time{
+		time.Date(2022, time.January, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2022, time.January, 2, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, time.December, 31, 12, 0, 0, 0, time.UTC),
+	} {
+		iy, iw := ISOWeeks.Week(t, nil)
+		uy, uw := USWeeks.Week(t, nil)
+		fmt.Printf("%s ISO %d-%02d US %d-%02d\n", t.Format("Mon 2006-01-02"), iy, iw, uy, uw)
+	}
+	// Output:
+	// Sat 2022-01-01 ISO 2021-52 US 2022-01
+	// Sun 2022-01-02 ISO 2021-52 US 2022-02
+	// Tue 2024-12-31 ISO 2025-01 US 2025-01
+}
+
+func TestWeekNumbering_iso(t *testing.T) {
+	loc := time.FixedZone("test", 13*3600)
+	day := time.Date(1999, time.December, 1, 6, 0, 0, 0, loc)
+	for range 3 * 366 {
+		iy, iw := day.ISOWeek()
+		if y, w := ISOWeeks.Week(day, nil); y != iy || w != iw {
+			t.Fatalf("%s: got %d-%d, want %d-%d", day, y, w, iy, iw)
+		}
+		day = AddDay(day, 1, nil)
+	}
+}
@@ -0,0 +1,83 @@
+package tiktak
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tracks are secondary time lines that may overlap the main time line. They
+// share the task tree with the main time line and, like day notes, are kept
+// by the root of the task tree.
+
+func validTrackName(n string) error {
+	if n == "" {
+		return fmt.Errorf("empty track name")
+	}
+	if strings.ContainsAny(n, "/@#\t\n\v\f\r \x85\xA0") {
+		return fmt.Errorf("invalid track name '%s'", n)
+	}
+	return nil
+}
+
+// Track returns the time line of track name or nil if there is no such track.
+func (t *Task) Track(name string) *TimeLine {
+	return t.Root().tracks[name]
+}
+
+// MakeTrack returns the time line of track name. The track is created if it
+// does not exist.
+func (t *Task) MakeTrack(name string) (*TimeLine, error) {
+	if err := validTrackName(name); err != nil {
+		return nil, err
+	}
+	root := t.Root()
+	if tl := root.tracks[name]; tl != nil {
+		return tl, nil
+	}
+	if root.tracks == nil {
+		root.tracks = make(map[string]*TimeLine)
+	}
+	tl := new(TimeLine)
+	root.tracks[name] = tl
+	return tl, nil
+}
+
+func (t *Task) DelTrack(name string) { delete(t.Root().tracks, name) }
+
+// Tracks returns the sorted names of all tracks.
+func (t *Task) Tracks() []string {
+	root := t.Root()
+	res := make([]string, 0, len(root.tracks))
+	for n := range root.tracks {
+		res = append(res, n)
+	}
+	slices.Sort(res)
+	return res
+}
+
+type trackSwitch struct {
+	track string
+	*Switch
+}
+
+// allSwitches returns the switches of tl and all tracks of root ordered by
+// time. Switches of tl come first if times are equal.
+func allSwitches(root *Task, tl TimeLine) []trackSwitch {
+	res := make([]trackSwitch, 0, len(tl))
+	for _, s := range tl {
+		res = append(res, trackSwitch{Switch: s})
+	}
+	if root == nil || len(root.tracks) == 0 {
+		return res
+	}
+	for _, n := range root.Tracks() {
+		for _, s := range *root.tracks[n] {
+			res = append(res, trackSwitch{track: n, Switch: s})
+		}
+	}
+	slices.SortStableFunc(res, func(a, b trackSwitch) int {
+		return a.When().Compare(b.When())
+	})
+	return res
+}